@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"monitor/internal/analysis"
@@ -21,28 +28,71 @@ import (
 // 4. (已迁移) 模板解析现在交由 web 包通过 go:embed 内部处理！
 // 5. 创建监控核心实例，并启动监控循环（独立goroutine）。
 // 6. 如果配置了SMTP，则异步执行邮件自检，确保系统重启时能发送通知。
-// 7. 创建Web处理器，注册路由，并启动HTTP服务器监听9090端口。
+// 7. 创建Web处理器，注册路由，并启动HTTP服务器监听配置的地址（默认 :9090）。
+// 8. 收到退出信号后优雅关闭 HTTP 服务，等待进行中的检查批次与缓冲的性能日志写完，最后关闭数据库。
 func main() {
+	// server hash-password：从标准输入读取口令，输出用于 MONITOR_DASHBOARD_PASSWORD_HASH 的哈希
+	if len(os.Args) > 1 && os.Args[1] == "hash-password" {
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		hash, err := web.HashDashboardPassword(strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			log.Fatal("hash password failed:", err)
+		}
+		fmt.Println(hash)
+		return
+	}
+
 	start := time.Now()
 	fmt.Println("🚀 哈基米监控系统（单文件部署终极版）启动...")
 
-	cfgMgr := config.NewManager("config.json")
-	if err := cfgMgr.LoadOrDefault(); err != nil {
-		log.Fatal("load config failed:", err)
-	}
+	var cfgMgr *config.Manager
+	var repo *repository.Repo
+	var err error
+	if os.Getenv("MONITOR_CONFIG_STORE") == "db" {
+		// 配置保存在数据库中，供指向同一数据库的多个实例共用；此时尚未读到配置，按默认次数重试连接
+		repo, err = repository.NewWithRetry("monitor.db", 3, time.Second)
+		if err != nil {
+			log.Fatal("init db failed:", err)
+		}
+		cfgMgr = config.NewManagerWithStore(repo.ConfigStore(config.ProfileName()))
+		if err := cfgMgr.LoadOrDefault(); err != nil {
+			log.Fatal("load config failed:", err)
+		}
+		log.Printf("🗄️ 配置存储于数据库: %s", config.ProfileName())
+	} else {
+		// 设置 MONITOR_PROFILE 时加载 config.<profile>.json，未设置时与以往一致使用 config.json
+		cfgMgr = config.NewManager(config.ProfilePath("config.json"))
+		if err := cfgMgr.LoadOrDefault(); err != nil {
+			log.Fatal("load config failed:", err)
+		}
 
-	repo, err := repository.New("monitor.db")
-	if err != nil {
-		log.Fatal("init db failed:", err)
+		// 数据库未就绪时按配置有限次重试，默认的快速重试对 SQLite 几乎无感
+		cfg := cfgMgr.Get()
+		repo, err = repository.NewWithRetry("monitor.db", cfg.DBConnectAttempts, time.Duration(cfg.DBConnectInterval)*time.Second)
+		if err != nil {
+			log.Fatal("init db failed:", err)
+		}
 	}
 
 	// ❌ 这里原本有 template.ParseFiles，现在光荣下岗了！
 
 	mon := monitor.New(cfgMgr, repo)
 	ai := analysis.New(cfgMgr, repo, mon)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// 收到 Ctrl+C / SIGTERM 时停止监控循环，并等待缓冲中的性能日志落库后再退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	go mon.Start(ctx)
+	// 共享数据库配置时定期载入其他实例保存的修改；本地文件配置时立即返回
+	go cfgMgr.WatchStore(ctx, 10*time.Second)
+
+	// 收到 SIGHUP 时重新打开告警日志，配合 logrotate 等工具轮转
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			mon.ReopenAlertLog()
+		}
+	}()
 
 	// 如果SMTP功能已启用，则进行邮件自检
 	if cfgMgr.Get().SMTP.Enabled {
@@ -60,8 +110,64 @@ func main() {
 	h := web.New(cfgMgr, repo, mon, ai, start)
 	mux := http.NewServeMux()
 	h.Register(mux)
+	go h.RunAutoBackup(ctx)
+
+	addr, err := config.ListenAddr(cfgMgr.Get())
+	if err != nil {
+		log.Fatal("invalid listen address:", err)
+	}
+	srv := &http.Server{Addr: addr, Handler: mux}
+	scheme := "http"
+	if tlsCfg := cfgMgr.Get().TLS; tlsCfg.Enabled {
+		srv.TLSConfig, err = web.ServerTLSConfig(tlsCfg)
+		if err != nil {
+			log.Fatal("init tls failed:", err)
+		}
+		scheme = "https"
+	}
+	host, port, _ := net.SplitHostPort(addr)
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	fmt.Println("🌐 管理后台:", scheme+"://"+net.JoinHostPort(host, port))
+	go func() {
+		var err error
+		if srv.TLSConfig != nil {
+			// 证书已加载到 TLSConfig 中，此处无需再传文件路径
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
 
-	addr := ":9090"
-	fmt.Println("🌐 管理后台:", "http://127.0.0.1"+addr)
-	log.Fatal(http.ListenAndServe(addr, mux))
+	<-ctx.Done()
+	fmt.Println("🛑 收到退出信号，正在停止服务...")
+	// 留足一轮检查的时间：进行中的批次受任务超时与重试约束，等待上限按配置估算
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), mon.ShutdownTimeout())
+	defer cancel()
+	clean := true
+	// 先停止接收请求，避免退出期间仍有新的手动检查或配置修改进入
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		fmt.Println("⚠️ 关闭 HTTP 服务超时:", err)
+		clean = false
+	}
+	if err := mon.Shutdown(shutdownCtx); err != nil {
+		// 仍有检查批次或性能日志写入在进行，此时关闭数据库会让它们写入已关闭的连接，直接以非零码退出
+		fmt.Println("⚠️", err)
+		fmt.Printf("👋 已退出，检查批次或性能日志未能按时完成，跳过关闭数据库（共运行 %s）\n", time.Since(start).Round(time.Second))
+		os.Exit(1)
+	}
+	if err := repo.Close(); err != nil {
+		fmt.Println("⚠️ 关闭数据库失败:", err)
+		clean = false
+	}
+	if clean {
+		fmt.Printf("👋 已安全退出：检查批次已完成，性能日志已落库，数据库已关闭（共运行 %s）\n", time.Since(start).Round(time.Second))
+	} else {
+		fmt.Printf("👋 已退出，但部分收尾未完成（共运行 %s）\n", time.Since(start).Round(time.Second))
+		os.Exit(1)
+	}
 }
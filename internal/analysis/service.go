@@ -99,7 +99,7 @@ func (s *Service) build(cfg model.AnalysisConfig) model.StabilityAnalysis {
 	results := s.mon.Results()
 	states := s.mon.StateSnapshot()
 	openAlerts := s.repo.QueryOpenAlerts()
-	recentEvents := s.repo.QueryEvents(cfg.DetailEventLimit)
+	recentEvents := s.repo.QueryEvents(cfg.DetailEventLimit, "")
 
 	openAlertMap := make(map[string]int)
 	for _, evt := range openAlerts {
@@ -212,7 +212,11 @@ func scoreTask(res model.MonitorResult, state model.TaskState, unresolvedAlerts
 		evidence = append(evidence, "当前探测结果为故障状态")
 	case "yellow":
 		score += 28
-		evidence = append(evidence, fmt.Sprintf("当前探测结果为慢响应（%s）", res.Duration))
+		if res.Status == "缓慢" || len(res.MissingHeaders) == 0 {
+			evidence = append(evidence, fmt.Sprintf("当前探测结果为慢响应（%s）", res.Duration))
+		} else {
+			evidence = append(evidence, "当前响应缺少安全头: "+strings.Join(res.MissingHeaders, ", "))
+		}
 	}
 
 	if state.ConsecutiveFails > 0 {
@@ -254,19 +258,26 @@ func scoreTask(res model.MonitorResult, state model.TaskState, unresolvedAlerts
 }
 
 func summarizePerformance(logs []model.PerformanceLog, fallback int64) (avg int64, last int64) {
-	if len(logs) == 0 {
+	// 失败样本的耗时多为超时时长，不计入响应时间统计
+	var total, n int64
+	last = -1
+	for _, item := range logs {
+		if item.Failed {
+			continue
+		}
+		if last < 0 {
+			last = item.ResponseTime
+		}
+		total += item.ResponseTime
+		n++
+	}
+	if n == 0 {
 		if fallback < 0 {
 			fallback = 0
 		}
 		return fallback, fallback
 	}
-
-	var total int64
-	last = logs[0].ResponseTime
-	for _, item := range logs {
-		total += item.ResponseTime
-	}
-	avg = total / int64(len(logs))
+	avg = total / n
 	if last <= 0 && fallback > 0 {
 		last = fallback
 	}
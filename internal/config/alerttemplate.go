@@ -0,0 +1,39 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// SampleAlertData 返回用于模板预览与保存校验的示例数据。
+func SampleAlertData() model.AlertTemplateData {
+	return model.AlertTemplateData{
+		TaskName:   "示例服务",
+		URL:        "https://api.example.com/health",
+		Status:     "故障",
+		StatusCode: 503,
+		FailReason: "状态码异常: 503",
+		FailCount:  3,
+		Duration:   "1.2s",
+		Time:       time.Now().Format("2006-01-02 15:04:05"),
+		Message:    "服务 [示例服务] 确认故障! (连续失败3次, 响应码:503) 原因: 状态码异常: 503",
+	}
+}
+
+// RenderAlertTemplate 用 text/template 渲染告警模板；模板语法错误或执行失败时返回错误。
+// 实际告警、/api/alert/preview 预览与保存设置时的校验使用同一渲染逻辑。
+func RenderAlertTemplate(tpl string, data model.AlertTemplateData) (string, error) {
+	t, err := template.New("alert").Option("missingkey=error").Parse(tpl)
+	if err != nil {
+		return "", fmt.Errorf("模板解析失败: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("模板渲染失败: %w", err)
+	}
+	return buf.String(), nil
+}
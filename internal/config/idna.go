@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+const acePrefix = "xn--"
+
+// hostProfile 按 IDNA2008 查询规则映射与校验主机名（含 NFC 规范化、大小写折叠与双向文本规则），
+// 但不强制 STD3 字符限制，以兼容内网常见的带下划线主机名。
+var hostProfile = idna.New(idna.MapForLookup(), idna.StrictDomainName(false), idna.BidiRule())
+
+// ToASCIIHost 将国际化域名（如 例え.jp）逐标签转换为 punycode（xn--r8jz45g.jp），
+// 纯 ASCII 的主机名与 IP 地址只做小写化。
+func ToASCIIHost(host string) (string, error) {
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	ascii, err := hostProfile.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("域名 %s 无法转换为 punycode: %v", host, err)
+	}
+	return ascii, nil
+}
+
+// ToUnicodeHost 将主机名中的 punycode 标签还原为 Unicode 以便展示，无法解码的标签保持原样。
+func ToUnicodeHost(host string) string {
+	labels := strings.Split(host, ".")
+	for i, label := range labels {
+		if len(label) <= len(acePrefix) || !strings.EqualFold(label[:len(acePrefix)], acePrefix) {
+			continue
+		}
+		if decoded, err := idna.Display.ToUnicode(label); err == nil {
+			labels[i] = decoded
+		}
+	}
+	return strings.Join(labels, ".")
+}
+
+// DisplayURL 返回主机名为 Unicode 形式的地址，供页面展示；不含 punycode 主机名或无法解析时原样返回。
+func DisplayURL(rawURL string) string {
+	if !strings.Contains(strings.ToLower(rawURL), acePrefix) {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	host := ToUnicodeHost(u.Hostname())
+	if host == u.Hostname() {
+		return rawURL
+	}
+	// 直接替换原串中的主机名，避免 url.URL.String() 对 Unicode 主机名转义
+	return strings.Replace(rawURL, u.Hostname(), host, 1)
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+// 取自 RFC 3492 第 7.1 节的样例（仅小写、可通过 IDNA2008 校验的条目）及常见域名。
+var idnaVectors = []struct {
+	unicode, ascii string
+}{
+	{"他们为什么不说中文", "xn--ihqwcrb4cv8a8dqg056pqjye"},    // (B) 简体中文
+	{"他們爲什麽不說中文", "xn--ihqwctvzc91f659drss3x8bo0yb"}, // (C) 繁体中文
+	{"ひとつ屋根の下2", "xn--2-u9tlzr9756bt3uc0v"},          // (K) 日文
+	{"そのスピードで", "xn--d9juau41awczczp"},               // (S) 日文
+	{"例え.jp", "xn--r8jz45g.jp"},
+	{"bücher.example", "xn--bcher-kva.example"},
+}
+
+func TestToASCIIHost(t *testing.T) {
+	for _, v := range idnaVectors {
+		got, err := ToASCIIHost(v.unicode)
+		if err != nil {
+			t.Errorf("ToASCIIHost(%q) 出错: %v", v.unicode, err)
+			continue
+		}
+		if got != v.ascii {
+			t.Errorf("ToASCIIHost(%q) = %q，期望 %q", v.unicode, got, v.ascii)
+		}
+	}
+
+	if got, _ := ToASCIIHost("Bücher.Example"); got != "xn--bcher-kva.example" {
+		t.Errorf("大写域名未折叠为小写: %q", got)
+	}
+	if got, _ := ToASCIIHost("my_host.internal"); got != "my_host.internal" {
+		t.Errorf("带下划线的主机名被改写: %q", got)
+	}
+}
+
+func TestToUnicodeHost(t *testing.T) {
+	for _, v := range idnaVectors {
+		if got := ToUnicodeHost(v.ascii); got != v.unicode {
+			t.Errorf("ToUnicodeHost(%q) = %q，期望 %q", v.ascii, got, v.unicode)
+		}
+	}
+	// 无法解码的标签保持原样
+	if got := ToUnicodeHost("xn--zz!.example"); got != "xn--zz!.example" {
+		t.Errorf("非法 punycode 标签被改写: %q", got)
+	}
+}
+
+func TestDisplayURL(t *testing.T) {
+	if got, want := DisplayURL("https://xn--r8jz45g.jp:8443/health?x=1"), "https://例え.jp:8443/health?x=1"; got != want {
+		t.Errorf("DisplayURL = %q，期望 %q", got, want)
+	}
+	if got := DisplayURL("https://example.com/"); got != "https://example.com/" {
+		t.Errorf("DisplayURL 改写了 ASCII 地址: %q", got)
+	}
+}
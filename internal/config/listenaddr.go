@@ -0,0 +1,45 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"monitor/internal/model"
+)
+
+// DefaultListenAddr 是未配置 listen_addr 时管理后台的监听地址。
+const DefaultListenAddr = ":9090"
+
+// NormalizeListenAddr 校验并规范化监听地址：空值取默认地址，仅写端口（如 "9191"）时补全为 ":9191"。
+func NormalizeListenAddr(addr string) (string, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return DefaultListenAddr, nil
+	}
+	if _, err := strconv.Atoi(addr); err == nil {
+		addr = ":" + addr
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("监听地址格式应为 [主机]:端口: %v", err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("监听端口 %q 无效，应在 1~65535 之间", port)
+	}
+	return addr, nil
+}
+
+// ListenAddr 返回启动时应监听的地址：环境变量 MONITOR_ADDR 优先，便于同机多实例各自指定端口，其次为配置中的 listen_addr。
+func ListenAddr(cfg model.Config) (string, error) {
+	if env := os.Getenv("MONITOR_ADDR"); env != "" {
+		addr, err := NormalizeListenAddr(env)
+		if err != nil {
+			return "", fmt.Errorf("MONITOR_ADDR: %w", err)
+		}
+		return addr, nil
+	}
+	return NormalizeListenAddr(cfg.ListenAddr)
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// maxMaintenanceWindows 是维护窗口的数量上限。
+const maxMaintenanceWindows = 50
+
+// maintenanceDateLayout 是一次性维护窗口 from/until 的时间格式（本地时区）。
+const maintenanceDateLayout = "2006-01-02 15:04"
+
+// NormalizeMaintenanceWindows 校验并规范化维护窗口：周期窗口需同时设置 start/end（"HH:MM"），
+// 一次性窗口需同时设置 from/until，两者不能混用；weekdays 取 0（周日）~6（周六），去重排序。
+func NormalizeMaintenanceWindows(windows []model.MaintenanceWindow) error {
+	if len(windows) > maxMaintenanceWindows {
+		return fmt.Errorf("维护窗口最多 %d 个", maxMaintenanceWindows)
+	}
+	for i := range windows {
+		if err := normalizeMaintenanceWindow(&windows[i], i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func normalizeMaintenanceWindow(w *model.MaintenanceWindow, index int) error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" {
+		w.Name = fmt.Sprintf("维护窗口 %d", index+1)
+	}
+	w.Start, w.End = strings.TrimSpace(w.Start), strings.TrimSpace(w.End)
+	w.From, w.Until = strings.TrimSpace(w.From), strings.TrimSpace(w.Until)
+	recurring := w.Start != "" || w.End != ""
+	oneOff := w.From != "" || w.Until != ""
+	switch {
+	case recurring && oneOff:
+		return fmt.Errorf("%s: start/end 与 from/until 不能同时设置", w.Name)
+	case recurring:
+		start, err := parseClock(w.Start)
+		if err != nil {
+			return fmt.Errorf("%s: 开始时间%v", w.Name, err)
+		}
+		end, err := parseClock(w.End)
+		if err != nil {
+			return fmt.Errorf("%s: 结束时间%v", w.Name, err)
+		}
+		if start == end {
+			return fmt.Errorf("%s: 开始与结束时间不能相同", w.Name)
+		}
+		for _, d := range w.Weekdays {
+			if d < 0 || d > 6 {
+				return fmt.Errorf("%s: weekdays 取值需在 0（周日）~6（周六）之间", w.Name)
+			}
+		}
+		slices.Sort(w.Weekdays)
+		w.Weekdays = slices.Compact(w.Weekdays)
+	case oneOff:
+		if len(w.Weekdays) > 0 {
+			return fmt.Errorf("%s: 一次性窗口不能设置 weekdays", w.Name)
+		}
+		from, err := time.ParseInLocation(maintenanceDateLayout, w.From, time.Local)
+		if err != nil {
+			return fmt.Errorf("%s: from 格式应为 YYYY-MM-DD HH:MM", w.Name)
+		}
+		until, err := time.ParseInLocation(maintenanceDateLayout, w.Until, time.Local)
+		if err != nil {
+			return fmt.Errorf("%s: until 格式应为 YYYY-MM-DD HH:MM", w.Name)
+		}
+		if !until.After(from) {
+			return fmt.Errorf("%s: until 需晚于 from", w.Name)
+		}
+	default:
+		return fmt.Errorf("%s: 需设置 start/end（每周重复）或 from/until（一次性）", w.Name)
+	}
+	for _, id := range w.TaskIDs {
+		if id <= 0 {
+			return fmt.Errorf("%s: 任务 ID 不合法: %d", w.Name, id)
+		}
+	}
+	return nil
+}
+
+// parseClock 解析 "HH:MM"，返回当天零点起的分钟数。
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("格式应为 HH:MM: %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// dropInvalidMaintenanceWindows 在加载配置时剔除不合法的维护窗口并记录日志，避免一条错误配置导致无法启动。
+func dropInvalidMaintenanceWindows(cfg *model.Config) {
+	if len(cfg.MaintenanceWindows) > maxMaintenanceWindows {
+		log.Printf("⚠️ 维护窗口超过 %d 个，多余的已忽略", maxMaintenanceWindows)
+		cfg.MaintenanceWindows = cfg.MaintenanceWindows[:maxMaintenanceWindows]
+	}
+	valid := cfg.MaintenanceWindows[:0]
+	for i, w := range cfg.MaintenanceWindows {
+		if err := normalizeMaintenanceWindow(&w, i); err != nil {
+			log.Printf("⚠️ 维护窗口配置无效，已忽略: %v", err)
+			continue
+		}
+		valid = append(valid, w)
+	}
+	cfg.MaintenanceWindows = valid
+}
+
+// ActiveMaintenance 返回 now 时刻对任务 taskID 生效的第一个维护窗口。
+// 跨午夜的周期窗口（end 早于 start）按开始当天的星期匹配。
+func ActiveMaintenance(windows []model.MaintenanceWindow, taskID int, now time.Time) (model.MaintenanceWindow, bool) {
+	for _, w := range windows {
+		if len(w.TaskIDs) > 0 && !slices.Contains(w.TaskIDs, taskID) {
+			continue
+		}
+		if maintenanceActive(w, now) {
+			return w, true
+		}
+	}
+	return model.MaintenanceWindow{}, false
+}
+
+func maintenanceActive(w model.MaintenanceWindow, now time.Time) bool {
+	if w.From != "" {
+		from, err1 := time.ParseInLocation(maintenanceDateLayout, w.From, time.Local)
+		until, err2 := time.ParseInLocation(maintenanceDateLayout, w.Until, time.Local)
+		return err1 == nil && err2 == nil && !now.Before(from) && now.Before(until)
+	}
+	start, err1 := parseClock(w.Start)
+	end, err2 := parseClock(w.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	onDay := func(d time.Weekday) bool {
+		return len(w.Weekdays) == 0 || slices.Contains(w.Weekdays, int(d))
+	}
+	minute := now.Hour()*60 + now.Minute()
+	if start < end {
+		return onDay(now.Weekday()) && minute >= start && minute < end
+	}
+	return (onDay(now.Weekday()) && minute >= start) || (onDay(now.AddDate(0, 0, -1).Weekday()) && minute < end)
+}
@@ -1,40 +1,125 @@
 package config
 
 import (
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net"
+	"net/http"
+	"net/mail"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	"monitor/internal/model"
 )
 
 // 🔥 AES 密钥来源：环境变量 MONITOR_SECRET_KEY（推荐），未提供则使用兼容的默认值。
 // 为兼容历史密文，默认值保持不变；生产环境请务必设置 MONITOR_SECRET_KEY。
-var secretKey = loadSecretKey()
+// 轮换密钥时把旧密钥放入 MONITOR_SECRET_KEYS_PREVIOUS（逗号分隔），旧密文仍可解密，加载后自动用新密钥重新加密。
+var secretKeys = loadSecretKeys()
 
-func loadSecretKey() []byte {
-	raw := os.Getenv("MONITOR_SECRET_KEY")
-	if raw == "" {
-		raw = "HakimiMonitorKey1234567890123456"
+// defaultSecretKey 是未设置 MONITOR_SECRET_KEY 时使用的兼容默认值。
+const defaultSecretKey = "HakimiMonitorKey1234567890123456"
+
+// secretCipherPrefix 标记带密钥编号的密文格式：enc:<密钥编号>:<base64>；无前缀的为历史格式。
+const secretCipherPrefix = "enc:"
+
+// secretKey 是密钥环中的一把 AES 密钥，id 由密钥内容派生，写入密文前缀用于解密时定位密钥。
+type secretKey struct {
+	id  string
+	key []byte
+}
+
+// loadSecretKeys 组装密钥环：第一把为当前密钥（加密只用它），其后为旧密钥，最后补上默认密钥以兼容历史密文。
+func loadSecretKeys() []secretKey {
+	raws := []string{os.Getenv("MONITOR_SECRET_KEY")}
+	if raws[0] == "" {
+		raws[0] = defaultSecretKey
+	}
+	for _, p := range strings.Split(os.Getenv("MONITOR_SECRET_KEYS_PREVIOUS"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			raws = append(raws, p)
+		}
+	}
+	raws = append(raws, defaultSecretKey)
+
+	keys := make([]secretKey, 0, len(raws))
+	seen := map[string]bool{}
+	for _, raw := range raws {
+		sum := sha256.Sum256([]byte(raw))
+		id := fmt.Sprintf("%x", sha256.Sum256(sum[:]))[:8]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys = append(keys, secretKey{id: id, key: sum[:]})
+	}
+	return keys
+}
+
+// isCurrentCiphertext 判断密文是否已使用当前密钥及带编号的格式，空值视为无需迁移。
+func isCurrentCiphertext(s string) bool {
+	return s == "" || strings.HasPrefix(s, secretCipherPrefix+secretKeys[0].id+":")
+}
+
+// needsReencrypt 检查落盘配置中是否存在历史格式或旧密钥加密的密文，需要在加载后重新加密。
+// 任务请求头的值与 Basic 认证密码同样检查：手工写入的明文也会在加载后立即加密落盘，而不是等到下一次无关的保存。
+func needsReencrypt(data []byte) bool {
+	var raw struct {
+		SMTP struct {
+			Password string `json:"password"`
+		} `json:"smtp"`
+		Webhook struct {
+			Secret string `json:"secret"`
+		} `json:"webhook"`
+		Analysis struct {
+			LLM struct {
+				APIKey string `json:"api_key"`
+			} `json:"llm"`
+		} `json:"analysis"`
+		Tasks []struct {
+			Headers       map[string]string `json:"headers"`
+			BasicAuthPass string            `json:"basic_auth_pass"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return false
 	}
-	sum := sha256.Sum256([]byte(raw))
-	return sum[:]
+	secrets := []string{raw.SMTP.Password, raw.Webhook.Secret, raw.Analysis.LLM.APIKey}
+	for _, t := range raw.Tasks {
+		for _, v := range t.Headers {
+			secrets = append(secrets, v)
+		}
+		secrets = append(secrets, t.BasicAuthPass)
+	}
+	for _, s := range secrets {
+		if !isCurrentCiphertext(s) {
+			return true
+		}
+	}
+	return false
 }
 
 type Manager struct {
-	mu   sync.RWMutex
-	path string
-	cfg  model.Config
+	mu    sync.RWMutex
+	store Store
+	cfg   model.Config
 }
 
 // ResetToExample 用 config.example.json 覆盖当前配置，并返回新配置。
@@ -47,7 +132,7 @@ func (m *Manager) ResetToExample(examplePath string) (model.Config, error) {
 	if err != nil {
 		return model.Config{}, err
 	}
-	var cfg model.Config
+	cfg := model.Config{NotificationsEnabled: true}
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return model.Config{}, err
 	}
@@ -61,8 +146,55 @@ func (m *Manager) ResetToExample(examplePath string) (model.Config, error) {
 	return cfg, nil
 }
 
+// NewManager 创建使用本地配置文件的管理器。
 func NewManager(path string) *Manager {
-	return &Manager{path: path}
+	return NewManagerWithStore(&FileStore{Path: path})
+}
+
+// NewManagerWithStore 创建使用指定存储后端的管理器（如多实例共享的数据库）。
+func NewManagerWithStore(store Store) *Manager {
+	return &Manager{store: store}
+}
+
+// Path 返回当前使用的配置文件路径；配置不在本地文件中时返回空串。
+func (m *Manager) Path() string {
+	if fs, ok := m.store.(*FileStore); ok {
+		return fs.Path
+	}
+	return ""
+}
+
+// profileNamePattern 限定环境名只能由字母、数字、下划线和连字符组成，避免拼出意外路径。
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ProfilePath 根据环境变量 MONITOR_PROFILE 选择配置文件：设置为 prod 时使用 config.prod.json，
+// 未设置、名称不合法或对应文件不存在时回退到 defaultPath，行为与未使用环境配置时一致。
+func ProfilePath(defaultPath string) string {
+	profile := strings.TrimSpace(os.Getenv("MONITOR_PROFILE"))
+	if profile == "" {
+		return defaultPath
+	}
+	if !profileNamePattern.MatchString(profile) {
+		log.Printf("⚠️ MONITOR_PROFILE=%q 不合法，使用默认配置 %s", profile, defaultPath)
+		return defaultPath
+	}
+	ext := filepath.Ext(defaultPath)
+	path := strings.TrimSuffix(defaultPath, ext) + "." + profile + ext
+	if _, err := os.Stat(path); err != nil {
+		log.Printf("⚠️ 环境配置 %s 不存在，使用默认配置 %s", path, defaultPath)
+		return defaultPath
+	}
+	log.Printf("🗂️ 已加载环境配置: %s", path)
+	return path
+}
+
+// ProfileName 返回 MONITOR_PROFILE 指定的环境名，未设置或不合法时返回 "default"，用作数据库中配置的名称。
+func ProfileName() string {
+	profile := strings.TrimSpace(os.Getenv("MONITOR_PROFILE"))
+	if profile == "" || !profileNamePattern.MatchString(profile) {
+		return "default"
+	}
+	return profile
 }
 
 // 🔥 通用加密函数
@@ -70,7 +202,8 @@ func encryptSecret(text string) string {
 	if text == "" {
 		return ""
 	}
-	block, err := aes.NewCipher(secretKey)
+	current := secretKeys[0]
+	block, err := aes.NewCipher(current.key)
 	if err != nil {
 		return text // 加密失败直接返回原值
 	}
@@ -83,36 +216,57 @@ func encryptSecret(text string) string {
 		return text
 	}
 	ciphertext := gcm.Seal(nonce, nonce, []byte(text), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext)
+	return secretCipherPrefix + current.id + ":" + base64.StdEncoding.EncodeToString(ciphertext)
 }
 
 // 🔥 通用解密函数：若密文损坏或被篡改，则返回错误并拒绝继续加载配置。
+// 带编号的密文只用对应密钥解密；无前缀的历史密文依次尝试密钥环中的每把密钥。
 func decryptSecret(cryptoText, fieldName string) (string, error) {
 	if cryptoText == "" {
 		return "", nil
 	}
-	data, err := base64.StdEncoding.DecodeString(cryptoText)
-	if err != nil {
-		return "", fmt.Errorf("%s不是有效密文，请通过系统设置重新保存配置", fieldName)
-	}
-	block, err := aes.NewCipher(secretKey)
-	if err != nil {
-		return "", err
+	keys := secretKeys
+	encoded := cryptoText
+	if rest, ok := strings.CutPrefix(cryptoText, secretCipherPrefix); ok {
+		id, b64, ok := strings.Cut(rest, ":")
+		if !ok {
+			return "", fmt.Errorf("%s不是有效密文，请通过系统设置重新保存配置", fieldName)
+		}
+		keys = nil
+		for _, k := range secretKeys {
+			if k.id == id {
+				keys = []secretKey{k}
+				break
+			}
+		}
+		if keys == nil {
+			return "", fmt.Errorf("%s使用的密钥 %s 不在密钥环中，请通过 MONITOR_SECRET_KEYS_PREVIOUS 提供旧密钥", fieldName, id)
+		}
+		encoded = b64
 	}
-	gcm, err := cipher.NewGCM(block)
+	data, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
-		return "", err
-	}
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("%s密文长度非法，配置可能已损坏", fieldName)
+		return "", fmt.Errorf("%s不是有效密文，请通过系统设置重新保存配置", fieldName)
 	}
-	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
-	if err != nil {
-		return "", fmt.Errorf("%s解密失败，配置可能已损坏或被篡改", fieldName)
+	for _, k := range keys {
+		block, err := aes.NewCipher(k.key)
+		if err != nil {
+			return "", err
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return "", err
+		}
+		nonceSize := gcm.NonceSize()
+		if len(data) < nonceSize {
+			return "", fmt.Errorf("%s密文长度非法，配置可能已损坏", fieldName)
+		}
+		nonce, ciphertext := data[:nonceSize], data[nonceSize:]
+		if plaintext, err := gcm.Open(nil, nonce, ciphertext, nil); err == nil {
+			return string(plaintext), nil
+		}
 	}
-	return string(plaintext), nil
+	return "", fmt.Errorf("%s解密失败，配置可能已损坏或被篡改", fieldName)
 }
 
 func encryptPassword(text string) string {
@@ -131,55 +285,231 @@ func decryptAPIKey(cryptoText string) (string, error) {
 	return decryptSecret(cryptoText, "LLM API Key")
 }
 
+// encryptTaskSecrets 返回加密了请求头值与 Basic 认证密码的任务列表副本，不修改内存中的明文配置。
+func encryptTaskSecrets(tasks []model.MonitorTask) []model.MonitorTask {
+	out := make([]model.MonitorTask, len(tasks))
+	for i, t := range tasks {
+		if len(t.Headers) > 0 {
+			headers := make(map[string]string, len(t.Headers))
+			for k, v := range t.Headers {
+				headers[k] = encryptSecret(v)
+			}
+			t.Headers = headers
+		}
+		t.BasicAuthPass = encryptSecret(t.BasicAuthPass)
+		out[i] = t
+	}
+	return out
+}
+
+// decryptTaskSecrets 就地解密任务请求头的值与 Basic 认证密码。手工写入配置文件的明文值（无 enc: 前缀）原样保留，下次保存时加密。
+func decryptTaskSecrets(tasks []model.MonitorTask) error {
+	for i := range tasks {
+		t := &tasks[i]
+		for k, v := range t.Headers {
+			if !strings.HasPrefix(v, secretCipherPrefix) {
+				continue
+			}
+			plain, err := decryptSecret(v, fmt.Sprintf("任务 [%s] 的请求头 %s ", t.Name, k))
+			if err != nil {
+				return err
+			}
+			t.Headers[k] = plain
+		}
+		if strings.HasPrefix(t.BasicAuthPass, secretCipherPrefix) {
+			plain, err := decryptSecret(t.BasicAuthPass, fmt.Sprintf("任务 [%s] 的 Basic 认证密码", t.Name))
+			if err != nil {
+				return err
+			}
+			t.BasicAuthPass = plain
+		}
+	}
+	return nil
+}
+
+func encryptWebhookSecret(text string) string {
+	return encryptSecret(text)
+}
+
+func decryptWebhookSecret(cryptoText string) (string, error) {
+	return decryptSecret(cryptoText, "Webhook 签名密钥")
+}
+
+// maxConfigSize 是配置文件的体积上限，超出视为损坏，避免异常文件拖垮启动。
+const maxConfigSize = 16 << 20
+
+// errConfigCorrupt 表示配置文件内容不可解析（截断、损坏或体积异常）。
+var errConfigCorrupt = errors.New("配置文件已损坏")
+
+// maxPerfWriteBatch 是性能日志单批写入条数的上限，避免单条 INSERT 超出 SQLite 变量数限制。
+const maxPerfWriteBatch = 500
+
+// defaultCertWarnDays 是证书到期预警的默认天数。
+const defaultCertWarnDays = 14
+
+// defaultRetryCount 是检查失败时的默认重试次数，配置为 0 表示不重试。
+const defaultRetryCount = 1
+
+// maxTaskWeight 是任务在状态汇总中的权重上限。
+const maxTaskWeight = 100
+
+// maxRequestTimeoutSec 是请求超时（任务 timeout 与全局 request_timeout_sec）允许的上限（秒）。
+// 一批检查要等最慢的任务完成，过长的超时会拖慢整轮调度。
+const maxRequestTimeoutSec = 300
+
+// allowedTaskMethods 是任务 method 允许的请求方法。
+var allowedTaskMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+}
+
+// maxTaskBodyBytes 是任务请求体的长度上限（字节）。
+const maxTaskBodyBytes = 64 << 10
+
+// maxPingCount 是 ping 任务每次检查发送回显请求数的上限。
+const maxPingCount = 20
+
+// maxTaskHeaders 是单个任务自定义请求头的数量上限。
+const maxTaskHeaders = 32
+
+// MaskedHeaderValue 是接口中代替请求头真实值展示的占位符。
+const MaskedHeaderValue = "***"
+
+// maxPerfKeepalive 是性能日志去重时最多连续跳过的样本数上限，避免图表出现过长的空档。
+const maxPerfKeepalive = 1000
+
+// defaultMinIntervalSec 是监控间隔的默认下限（秒）。
+const defaultMinIntervalSec = 5
+
+// defaultBodyPreviewBytes 与 maxBodyPreviewBytes 是告警响应体预览的默认长度与上限（字节）。
+const (
+	defaultBodyPreviewBytes = 512
+	maxBodyPreviewBytes     = 4096
+)
+
+func defaultConfig() model.Config {
+	cfg := model.Config{
+		Interval:             5,
+		MinIntervalSec:       defaultMinIntervalSec,
+		BodyPreviewBytes:     defaultBodyPreviewBytes,
+		AlertThreshold:       3,
+		AlertCooldown:        60,
+		RetryCount:           defaultRetryCount,
+		CertWarnDays:         defaultCertWarnDays,
+		NotificationsEnabled: true,
+		Analysis: model.AnalysisConfig{
+			Enabled:               true,
+			CacheSeconds:          60,
+			DetailEventLimit:      20,
+			PerformanceSampleSize: 10,
+			SlowThresholdMS:       800,
+			LLM: model.LLMConfig{
+				BaseURL:        "https://api.openai.com/v1/chat/completions",
+				Model:          "gpt-4o-mini",
+				TimeoutSeconds: 20,
+			},
+		},
+		Tasks: []model.MonitorTask{
+			{ID: 1, Name: "百度搜索", URL: "https://www.baidu.com"},
+		},
+	}
+	applyConfigDefaults(&cfg)
+	return cfg
+}
+
+// decodeConfig 解析落盘配置并解密敏感字段。
+// 内容不可解析时返回包装了 errConfigCorrupt 的错误，解密失败则原样返回。
+func decodeConfig(data []byte) (model.Config, error) {
+	// 零值有明确含义（如 0 表示关闭）的字段在解码前预置默认值，只有配置文件未写该字段时才生效
+	cfg := model.Config{CertWarnDays: defaultCertWarnDays, NotificationsEnabled: true, RetryCount: defaultRetryCount}
+	if len(data) > maxConfigSize {
+		return cfg, fmt.Errorf("%w: 文件大小 %d 字节超出上限", errConfigCorrupt, len(data))
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%w: %v", errConfigCorrupt, err)
+	}
+
+	// 🔥 读取时，将密文还原成明文供系统内部使用；解密失败则拒绝加载。
+	password, err := decryptPassword(cfg.SMTP.Password)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.SMTP.Password = password
+
+	apiKey, err := decryptAPIKey(cfg.Analysis.LLM.APIKey)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Analysis.LLM.APIKey = apiKey
+
+	webhookSecret, err := decryptWebhookSecret(cfg.Webhook.Secret)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Webhook.Secret = webhookSecret
+
+	if err := decryptTaskSecrets(cfg.Tasks); err != nil {
+		return cfg, err
+	}
+
+	applyConfigDefaults(&cfg)
+	return cfg, nil
+}
+
+// LoadOrDefault 加载配置；尚无配置时写入默认配置。
+// 若本地配置文件损坏，会将其另存为 .corrupt-<时间戳>，依次尝试 .bak 备份与默认配置启动，
+// 保证监控服务的可用性不依赖于一份完好的配置文件。
+// 其他存储后端（如共享数据库）中的配置损坏时直接返回错误，避免用默认配置覆盖其他实例共用的配置。
 func (m *Manager) LoadOrDefault() error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	data, err := os.ReadFile(m.path)
+	data, err := m.store.Load()
 	if err != nil {
-		m.cfg = model.Config{
-			Interval:       5,
-			AlertThreshold: 3,
-			AlertCooldown:  60,
-			Analysis: model.AnalysisConfig{
-				Enabled:               true,
-				CacheSeconds:          60,
-				DetailEventLimit:      20,
-				PerformanceSampleSize: 10,
-				SlowThresholdMS:       800,
-				LLM: model.LLMConfig{
-					BaseURL:        "https://api.openai.com/v1/chat/completions",
-					Model:          "gpt-4o-mini",
-					TimeoutSeconds: 20,
-				},
-			},
-			Tasks: []model.MonitorTask{
-				{ID: 1, Name: "百度搜索", URL: "https://www.baidu.com"},
-			},
+		if _, isFile := m.store.(*FileStore); !isFile && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("读取配置失败: %w", err)
 		}
-		applyConfigDefaults(&m.cfg)
+		m.cfg = defaultConfig()
 		return m.saveLocked()
 	}
-	if err := json.Unmarshal(data, &m.cfg); err != nil {
-		return err
+	cfg, err := decodeConfig(data)
+	if err == nil {
+		m.cfg = cfg
+		// 历史格式或旧密钥加密的密文在加载后用当前密钥重新落盘，完成密钥轮换迁移
+		if needsReencrypt(data) {
+			if err := m.saveLocked(); err != nil {
+				return err
+			}
+			log.Printf("🔑 已使用当前密钥重新加密配置中的敏感字段")
+		}
+		return nil
 	}
-
-	// 🔥 读取时，将密文还原成明文供系统内部使用；解密失败则拒绝加载。
-	password, err := decryptPassword(m.cfg.SMTP.Password)
-	if err != nil {
+	if !errors.Is(err, errConfigCorrupt) {
 		return err
 	}
-	m.cfg.SMTP.Password = password
 
-	apiKey, err := decryptAPIKey(m.cfg.Analysis.LLM.APIKey)
-	if err != nil {
+	rs, ok := m.store.(recoverableStore)
+	if !ok {
 		return err
 	}
-	m.cfg.Analysis.LLM.APIKey = apiKey
+	log.Printf("⚠️ 配置文件 %s 不可用: %v，尝试从备份恢复", m.Path(), err)
+	corruptPath, qErr := rs.Quarantine()
+	if qErr == nil {
+		log.Printf("⚠️ 损坏的配置已另存为 %s", corruptPath)
+	}
 
-	applyConfigDefaults(&m.cfg)
-	return nil
+	if bak, backupPath, readErr := rs.LoadBackup(); readErr == nil {
+		cfg, bakErr := decodeConfig(bak)
+		if bakErr == nil {
+			log.Printf("✅ 已从备份 %s 恢复配置", backupPath)
+			m.cfg = cfg
+			return m.saveLocked()
+		}
+		log.Printf("❌ 备份 %s 同样不可用: %v", backupPath, bakErr)
+	}
 
+	log.Printf("❌ 未找到可用备份，回退到默认配置启动，请尽快检查 %s", corruptPath)
+	m.cfg = defaultConfig()
+	return m.saveLocked()
 }
 
 func (m *Manager) Get() model.Config {
@@ -189,84 +519,401 @@ func (m *Manager) Get() model.Config {
 }
 
 // NormalizeAndValidateTaskInput 统一规范化并校验监控任务输入。
-// 对未带协议的地址统一补全为 http://，并校验 URL、主机名和可解析性。
-func NormalizeAndValidateTaskInput(name, rawURL string) (string, string, error) {
+// http 任务对未带协议的地址统一补全为 http://，并校验 URL、主机名和可解析性；tcp 任务的地址为 host:port，
+// ping 任务的地址为主机名或 IP。
+// 地址为模板（如 https://{{.host}}/health）时按全局变量 global 与任务变量 vars 展开后校验，返回的仍是模板本身。
+func NormalizeAndValidateTaskInput(name, rawURL, taskType string, global, vars map[string]string) (string, string, error) {
 	name = strings.TrimSpace(name)
 	rawURL = strings.TrimSpace(rawURL)
 	if name == "" || rawURL == "" {
 		return "", "", fmt.Errorf("name/url 不能为空")
 	}
 
+	if IsURLTemplate(rawURL) {
+		expanded, err := ExpandURL(rawURL, global, vars)
+		if err != nil {
+			return "", "", err
+		}
+		if err := validateExpandedTarget(expanded, taskType); err != nil {
+			return "", "", err
+		}
+		return name, rawURL, nil
+	}
+
+	rawURL, err := taskTargetNormalizer(taskType)(rawURL)
+	if err != nil {
+		return "", "", err
+	}
+	return name, rawURL, nil
+}
+
+// normalizeTaskURL 为缺少协议的地址补全 http://，并校验协议、主机名及域名可解析。
+func normalizeTaskURL(rawURL string) (string, error) {
 	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
 		rawURL = "http://" + rawURL
 	}
 
 	u, err := url.ParseRequestURI(rawURL)
 	if err != nil {
-		return "", "", fmt.Errorf("URL 格式不合法: %v", err)
+		return "", fmt.Errorf("URL 格式不合法: %v", err)
 	}
 	if u.Scheme != "http" && u.Scheme != "https" {
-		return "", "", fmt.Errorf("仅支持 http/https")
+		return "", fmt.Errorf("仅支持 http/https")
 	}
 	host := u.Hostname()
 	if host == "" {
-		return "", "", fmt.Errorf("URL 缺少主机名")
+		return "", fmt.Errorf("URL 缺少主机名")
+	}
+	asciiHost, err := validateTaskHost(host)
+	if err != nil {
+		return "", err
+	}
+	return strings.Replace(rawURL, host, asciiHost, 1), nil
+}
+
+// taskTargetNormalizer 返回任务类型对应的地址规范化函数。
+func taskTargetNormalizer(taskType string) func(string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(taskType)) {
+	case model.TaskTypeTCP:
+		return normalizeTCPAddress
+	case model.TaskTypePing:
+		return normalizePingHost
+	default:
+		return normalizeTaskURL
+	}
+}
+
+// normalizePingHost 校验 ping 任务的主机名或 IP（IPv6 可带方括号，保存时去掉）。
+func normalizePingHost(host string) (string, error) {
+	host = strings.Trim(host, "[]")
+	if strings.ContainsAny(host, "/?#@ ") || (strings.Contains(host, ":") && net.ParseIP(host) == nil) {
+		return "", fmt.Errorf("ping 任务的地址只需填写主机名或 IP: %s", host)
+	}
+	return validateTaskHost(host)
+}
+
+// normalizeTCPAddress 校验 tcp 任务的 host:port 地址（可带 tcp:// 前缀，保存时去掉），端口需在 1~65535 之间。
+func normalizeTCPAddress(addr string) (string, error) {
+	addr = strings.TrimPrefix(addr, "tcp://")
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("TCP 地址需写作 host:port: %v", err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("端口不合法: %s", port)
+	}
+	if host == "" {
+		return "", fmt.Errorf("TCP 地址缺少主机名")
+	}
+	asciiHost, err := validateTaskHost(host)
+	if err != nil {
+		return "", err
 	}
+	return net.JoinHostPort(asciiHost, port), nil
+}
 
+// validateTaskHost 校验任务主机名：国际化域名统一转换为 punycode 保存与检查，域名需完整且可解析。
+func validateTaskHost(host string) (string, error) {
+	if !isASCII(host) {
+		asciiHost, err := ToASCIIHost(host)
+		if err != nil {
+			return "", err
+		}
+		host = asciiHost
+	}
 	if net.ParseIP(host) == nil {
 		if !strings.Contains(host, ".") && host != "localhost" {
-			return "", "", fmt.Errorf("域名不合法，请输入完整域名")
+			return "", fmt.Errorf("域名不合法，请输入完整域名")
 		}
 		if _, err := net.LookupHost(host); err != nil {
-			return "", "", fmt.Errorf("域名无法解析: %s", host)
+			return "", fmt.Errorf("域名无法解析: %s", host)
 		}
 	}
+	return host, nil
+}
 
-	return name, rawURL, nil
+// GetTask 按 ID 返回任务配置副本，第二个返回值表示是否找到。
+func (m *Manager) GetTask(id int) (model.MonitorTask, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, t := range m.cfg.Tasks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return model.MonitorTask{}, false
+}
+
+// FindTaskByName 按名称返回第一个匹配的任务配置副本，第二个返回值表示是否找到。
+func (m *Manager) FindTaskByName(name string) (model.MonitorTask, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, t := range m.cfg.Tasks {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return model.MonitorTask{}, false
+}
+
+// maxRedirectsLimit 是可配置的最大跳转次数上限（全局与任务级均适用）。
+const maxRedirectsLimit = 30
+
+// maxBodyRegexLen 是任务响应体正则的长度上限。
+const maxBodyRegexLen = 512
+
+// contentTypePrefixPattern 匹配合法的媒体类型或其前缀（如 "application/json"、"text/"）。
+var contentTypePrefixPattern = regexp.MustCompile(`^[a-z0-9!#$&^_.+-]+(/[a-z0-9!#$&^_.+-]*)?$`)
+
+// ValidateTaskOptions 校验并规范化任务的扩展选项（名称与 URL 之外的字段）。task.URL 应为已规范化的地址，
+// 模板地址按 global 与任务变量展开后再做协议相关的检查。
+func ValidateTaskOptions(task *model.MonitorTask, global map[string]string) error {
+	target := task.URL
+	if expanded, err := ExpandURL(task.URL, global, task.Vars); err == nil {
+		target = expanded
+	}
+	for _, code := range task.RetryOnStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("重试状态码不合法: %d", code)
+		}
+	}
+	if len(task.MustContain) > maxBodyRegexLen || len(task.MustNotContain) > maxBodyRegexLen {
+		return fmt.Errorf("响应体关键字过长（最多 %d 个字符）", maxBodyRegexLen)
+	}
+	if task.BodyRegex != "" {
+		if len(task.BodyRegex) > maxBodyRegexLen {
+			return fmt.Errorf("响应体正则过长（最多 %d 个字符）", maxBodyRegexLen)
+		}
+		if _, err := regexp.Compile(task.BodyRegex); err != nil {
+			return fmt.Errorf("响应体正则不合法: %v", err)
+		}
+	}
+	if task.MaxRedirects < 0 || task.MaxRedirects > maxRedirectsLimit {
+		return fmt.Errorf("最大跳转次数需在 0~%d 之间", maxRedirectsLimit)
+	}
+	if task.MaxResponseMS < 0 {
+		return fmt.Errorf("最长响应时间不能为负数")
+	}
+	if task.Timeout < 0 || task.Timeout > maxRequestTimeoutSec {
+		return fmt.Errorf("请求超时应在 0~%d 秒之间", maxRequestTimeoutSec)
+	}
+	if err := normalizeTaskType(task); err != nil {
+		return err
+	}
+	if err := normalizeTaskMethod(task); err != nil {
+		return err
+	}
+	if err := normalizeTaskHeaders(task); err != nil {
+		return err
+	}
+	if err := normalizeBasicAuth(task); err != nil {
+		return err
+	}
+	if task.ExpectedStatus = strings.TrimSpace(task.ExpectedStatus); task.ExpectedStatus != "" {
+		ranges, err := ParseStatusRanges(task.ExpectedStatus)
+		if err != nil {
+			return err
+		}
+		task.ExpectedStatus = ranges.String()
+	}
+	// 期望的内容类型只比较媒体类型，去掉 charset 等参数并统一小写
+	task.ExpectContentType = strings.ToLower(strings.TrimSpace(strings.SplitN(task.ExpectContentType, ";", 2)[0]))
+	if task.ExpectContentType != "" && !contentTypePrefixPattern.MatchString(task.ExpectContentType) {
+		return fmt.Errorf("期望的 Content-Type 格式不正确: %s", task.ExpectContentType)
+	}
+	if task.DependsOn < 0 || (task.ID != 0 && task.DependsOn == task.ID) {
+		return fmt.Errorf("上游任务 ID 不合法: %d", task.DependsOn)
+	}
+	if task.SLATargetMS < 0 {
+		return fmt.Errorf("SLA 响应时间目标不能为负数")
+	}
+	if task.Weight < 0 || task.Weight > maxTaskWeight {
+		return fmt.Errorf("任务权重应在 0~%d 之间", maxTaskWeight)
+	}
+	if err := normalizeSecurityHeaders(task); err != nil {
+		return err
+	}
+	if task.PerfDedupPct < 0 || task.PerfDedupPct > 100 {
+		return fmt.Errorf("性能日志去重阈值应在 0~100 之间")
+	}
+	if task.PerfKeepaliveEvery < 0 || task.PerfKeepaliveEvery > maxPerfKeepalive {
+		return fmt.Errorf("性能日志保活间隔应在 0~%d 个样本之间", maxPerfKeepalive)
+	}
+	if task.BurnRate != nil {
+		if err := NormalizeBurnRate(task.BurnRate); err != nil {
+			return err
+		}
+	}
+	if task.UseHTTP3 {
+		if strings.HasPrefix(strings.ToLower(target), "http://") {
+			return fmt.Errorf("HTTP/3 仅支持 https 地址")
+		}
+		if task.SourceIP != "" {
+			return fmt.Errorf("HTTP/3 检查暂不支持指定出口源地址")
+		}
+	}
+	task.AlertTo = strings.TrimSpace(task.AlertTo)
+	if task.AlertTo != "" {
+		if _, err := mail.ParseAddressList(task.AlertTo); err != nil {
+			return fmt.Errorf("告警收件人格式不正确: %v", err)
+		}
+	}
+	task.AlertWebhook = strings.TrimSpace(task.AlertWebhook)
+	if task.AlertWebhook != "" {
+		u, err := url.Parse(task.AlertWebhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("告警 Webhook 地址需为 http(s) 链接")
+		}
+	}
+	task.Tenant = strings.TrimSpace(task.Tenant)
+	if utf8.RuneCountInString(task.Tenant) > 64 {
+		return fmt.Errorf("项目名称过长（最多 64 个字符）")
+	}
+	task.SourceIP = strings.TrimSpace(task.SourceIP)
+	if task.SourceIP != "" {
+		if err := validateLocalIP(task.SourceIP); err != nil {
+			return err
+		}
+	}
+	task.ClientCertPath = strings.TrimSpace(task.ClientCertPath)
+	task.ClientKeyPath = strings.TrimSpace(task.ClientKeyPath)
+	if (task.ClientCertPath == "") != (task.ClientKeyPath == "") {
+		return fmt.Errorf("客户端证书与私钥需同时配置")
+	}
+	if task.ClientCertPath != "" {
+		if _, err := tls.LoadX509KeyPair(task.ClientCertPath, task.ClientKeyPath); err != nil {
+			return fmt.Errorf("加载客户端证书失败: %v", err)
+		}
+	}
+	if task.CertExpiryAlertDays != nil && *task.CertExpiryAlertDays < 0 {
+		return fmt.Errorf("证书预警天数不能为负数")
+	}
+	if task.RequireHTTPSRedirect && !strings.HasPrefix(strings.ToLower(strings.TrimSpace(target)), "http://") {
+		return fmt.Errorf("HTTPS 跳转检查仅适用于 http:// 地址")
+	}
+	keys := task.RequiredKeys[:0]
+	for _, k := range task.RequiredKeys {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	task.RequiredKeys = keys
+	if len(task.RequiredKeys) > 0 {
+		// 指定了必需键即意味着需要解析 JSON
+		task.ValidateJSON = true
+	}
+	return nil
 }
 
-func (m *Manager) AddTask(name, rawURL string) (model.MonitorTask, error) {
+// validateLocalIP 校验源地址是合法 IP 且属于本机某个网卡。
+func validateLocalIP(raw string) error {
+	ip := net.ParseIP(raw)
+	if ip == nil {
+		return fmt.Errorf("源地址不合法: %s", raw)
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return fmt.Errorf("读取本机网卡地址失败: %v", err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("源地址 %s 不属于本机任何网卡", raw)
+}
+
+// AddTask 新增监控任务：ID 由发号器分配，标星等界面状态不从输入继承。
+func (m *Manager) AddTask(in model.MonitorTask) (model.MonitorTask, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	var err error
-	name, rawURL, err = NormalizeAndValidateTaskInput(name, rawURL)
+	name, rawURL, err := NormalizeAndValidateTaskInput(in.Name, in.URL, in.Type, m.cfg.Vars, in.Vars)
 	if err != nil {
 		return model.MonitorTask{}, err
 	}
+	in.URL = rawURL
+	if err := ValidateTaskOptions(&in, m.cfg.Vars); err != nil {
+		return model.MonitorTask{}, err
+	}
 
 	// 直接用发号器的号码创建任务
-	task := model.MonitorTask{
-		ID:   m.cfg.NextTaskID, // 🔥 从全局发号器取号
-		Name: name,
-		URL:  rawURL,
-	}
+	task := in
+	task.ID = m.cfg.NextTaskID // 🔥 从全局发号器取号
+	task.Name = name
+	task.URL = rawURL
+	task.Starred = false
+	task.Archived = false
+	task.Paused = false
+	task.ManagedBy = ""
 
 	m.cfg.NextTaskID++ // 🔥 发号器自增（永远向前，绝不回头！）
 	m.cfg.Tasks = append(m.cfg.Tasks, task)
 	return task, m.saveLocked()
 }
 
+// CloneTask 复制指定任务的配置为新任务：分配新 ID、名称追加 " (copy)"，
+// 标星、归档与管理来源等状态不复制，副本视为手动添加的任务。
+func (m *Manager) CloneTask(id int) (model.MonitorTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, t := range m.cfg.Tasks {
+		if t.ID != id {
+			continue
+		}
+		task := t
+		task.ID = m.cfg.NextTaskID
+		task.Name = t.Name + " (copy)"
+		task.Starred = false
+		task.Archived = false
+		task.Paused = false
+		task.ManagedBy = ""
+		task.ExternalKey = ""
+		task.RetryOnStatus = append([]int(nil), t.RetryOnStatus...)
+		task.RequiredKeys = append([]string(nil), t.RequiredKeys...)
+		if t.CertExpiryAlertDays != nil {
+			days := *t.CertExpiryAlertDays
+			task.CertExpiryAlertDays = &days
+		}
+
+		m.cfg.NextTaskID++
+		m.cfg.Tasks = append(m.cfg.Tasks, task)
+		return task, m.saveLocked()
+	}
+	return model.MonitorTask{}, fmt.Errorf("未找到指定任务")
+}
+
 // UpdateTask 修改现有监控任务，返回更新后的任务和旧 URL（供上层清理缓存使用）。
-func (m *Manager) UpdateTask(id int, name, rawURL string) (model.MonitorTask, string, error) {
+// 标星、归档、管理来源等状态保持不变，其余字段以输入为准。
+func (m *Manager) UpdateTask(in model.MonitorTask) (model.MonitorTask, string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if id <= 0 {
+	if in.ID <= 0 {
 		return model.MonitorTask{}, "", fmt.Errorf("invalid id")
 	}
 
-	var err error
-	name, rawURL, err = NormalizeAndValidateTaskInput(name, rawURL)
+	name, rawURL, err := NormalizeAndValidateTaskInput(in.Name, in.URL, in.Type, m.cfg.Vars, in.Vars)
 	if err != nil {
 		return model.MonitorTask{}, "", err
 	}
+	in.URL = rawURL
+	if err := ValidateTaskOptions(&in, m.cfg.Vars); err != nil {
+		return model.MonitorTask{}, "", err
+	}
 
 	for i := range m.cfg.Tasks {
-		if m.cfg.Tasks[i].ID == id {
+		if m.cfg.Tasks[i].ID == in.ID {
 			oldURL := m.cfg.Tasks[i].URL
-			m.cfg.Tasks[i].Name = name
-			m.cfg.Tasks[i].URL = rawURL
+			task := in
+			task.Name = name
+			task.URL = rawURL
+			task.Starred = m.cfg.Tasks[i].Starred
+			task.Archived = m.cfg.Tasks[i].Archived
+			task.Paused = m.cfg.Tasks[i].Paused
+			task.ManagedBy = m.cfg.Tasks[i].ManagedBy
+			m.cfg.Tasks[i] = task
 			if err := m.saveLocked(); err != nil {
 				return model.MonitorTask{}, "", err
 			}
@@ -297,19 +944,124 @@ func (m *Manager) UpdateSettings(in model.Config) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	// 未提交 vars 时保留原有全局变量；提交时须保证所有模板任务仍能展开为合法地址
+	if in.Vars == nil {
+		in.Vars = m.cfg.Vars
+	} else if err := validateTemplatedTasks(m.cfg.Tasks, m.cfg.Vars, in.Vars); err != nil {
+		return fmt.Errorf("全局变量校验失败: %v", err)
+	}
+
+	// 告警模板用示例数据试渲染一次，语法错误或字段名拼错时拒绝保存，避免真实告警时才发现模板不可用
+	in.AlertTemplate = strings.TrimSpace(in.AlertTemplate)
+	if in.AlertTemplate != "" {
+		if _, err := RenderAlertTemplate(in.AlertTemplate, SampleAlertData()); err != nil {
+			return fmt.Errorf("告警%v", err)
+		}
+	}
+
 	if in.Interval <= 0 {
 		in.Interval = 5
 	}
+	if in.Interval < m.cfg.MinIntervalSec {
+		return fmt.Errorf("监控间隔 %d 秒低于下限 %d 秒，确需更高频率请在配置文件中调低 min_interval_sec", in.Interval, m.cfg.MinIntervalSec)
+	}
 	if in.AlertThreshold <= 0 {
 		in.AlertThreshold = 3
 	}
 	if in.AlertCooldown < 0 {
 		in.AlertCooldown = 60
 	}
+	if in.RetryCount < 0 {
+		in.RetryCount = m.cfg.RetryCount
+	}
+	if in.MaxRedirects <= 0 {
+		in.MaxRedirects = m.cfg.MaxRedirects
+	}
+	in.MaxRedirects = min(in.MaxRedirects, maxRedirectsLimit)
+	if in.ConnectTimeoutSec < 0 {
+		in.ConnectTimeoutSec = 0
+	}
+	in.RequestTimeoutSec = min(max(in.RequestTimeoutSec, 0), maxRequestTimeoutSec)
+	if in.ManualCheckInterval <= 0 {
+		in.ManualCheckInterval = m.cfg.ManualCheckInterval
+	}
+	if in.GroupAlertWindowSec < 0 {
+		in.GroupAlertWindowSec = 0
+	}
+	if in.MinRecoverSec < 0 {
+		in.MinRecoverSec = 0
+	}
+	if in.WarmupChecks < 0 {
+		in.WarmupChecks = 0
+	}
+	if in.SlowAlertChecks < 0 {
+		in.SlowAlertChecks = 0
+	}
+	if in.DNSFailThreshold < 0 {
+		in.DNSFailThreshold = 0
+	}
+	if in.BackupIntervalHours < 0 {
+		in.BackupIntervalHours = 0
+	}
+	in.DiscoveryURL = strings.TrimSpace(in.DiscoveryURL)
+	if in.DiscoveryURL != "" {
+		u, err := url.Parse(in.DiscoveryURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("任务发现地址需为 http(s) 链接")
+		}
+	}
+	if in.DiscoveryIntervalMin <= 0 {
+		in.DiscoveryIntervalMin = m.cfg.DiscoveryIntervalMin
+	}
+	if in.BackupKeep <= 0 {
+		in.BackupKeep = m.cfg.BackupKeep
+	}
+	if in.DNSCacheTTL <= 0 {
+		in.DNSCacheTTL = m.cfg.DNSCacheTTL
+	}
+	if in.CertWarnDays < 0 {
+		in.CertWarnDays = m.cfg.CertWarnDays
+	}
+	if in.MaxConcurrency < 0 {
+		in.MaxConcurrency = 0
+	}
+	if in.SparklineSize <= 0 {
+		in.SparklineSize = m.cfg.SparklineSize
+	}
+	clampSparklineSize(&in)
+	clampDNSCacheTTL(&in)
 
 	if strings.TrimSpace(in.SMTP.Password) == "" {
 		in.SMTP.Password = m.cfg.SMTP.Password
 	}
+	if in.SMTP.RetryMax <= 0 {
+		in.SMTP.RetryMax = m.cfg.SMTP.RetryMax
+	}
+	if in.BodyPreviewBytes <= 0 {
+		in.BodyPreviewBytes = m.cfg.BodyPreviewBytes
+	}
+	in.BodyPreviewBytes = min(in.BodyPreviewBytes, maxBodyPreviewBytes)
+	in.SMTP.To = strings.TrimSpace(in.SMTP.To)
+	if in.SMTP.To != "" {
+		if _, err := mail.ParseAddressList(in.SMTP.To); err != nil {
+			return fmt.Errorf("收件人邮箱格式不正确（多个地址用英文逗号分隔）: %v", err)
+		}
+	}
+	if strings.TrimSpace(in.Webhook.Secret) == "" {
+		in.Webhook.Secret = m.cfg.Webhook.Secret
+	}
+	in.Webhook.URL = strings.TrimSpace(in.Webhook.URL)
+	in.Webhook.Template = strings.TrimSpace(in.Webhook.Template)
+	if in.Webhook.Template != "" {
+		if err := ValidateWebhookTemplate(in.Webhook.Template); err != nil {
+			return err
+		}
+	}
+	if in.Webhook.Enabled {
+		if u, err := url.ParseRequestURI(in.Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("Webhook 地址不合法")
+		}
+	}
 	if in.Analysis.CacheSeconds <= 0 {
 		in.Analysis.CacheSeconds = m.cfg.Analysis.CacheSeconds
 	}
@@ -335,55 +1087,372 @@ func (m *Manager) UpdateSettings(in model.Config) error {
 		in.Analysis.LLM.APIKey = m.cfg.Analysis.LLM.APIKey
 	}
 	normalizeAnalysisConfig(&in.Analysis)
+	if in.ErrorBudget.MaxFailurePct <= 0 {
+		in.ErrorBudget.MaxFailurePct = m.cfg.ErrorBudget.MaxFailurePct
+	}
+	if in.ErrorBudget.WindowMinutes <= 0 {
+		in.ErrorBudget.WindowMinutes = m.cfg.ErrorBudget.WindowMinutes
+	}
+	if in.ErrorBudget.MinSamples <= 0 {
+		in.ErrorBudget.MinSamples = m.cfg.ErrorBudget.MinSamples
+	}
+	normalizeErrorBudget(&in.ErrorBudget)
+	if in.Flap.Transitions <= 0 {
+		in.Flap.Transitions = m.cfg.Flap.Transitions
+	}
+	if in.Flap.WindowMinutes <= 0 {
+		in.Flap.WindowMinutes = m.cfg.Flap.WindowMinutes
+	}
+	if in.Flap.StableMinutes <= 0 {
+		in.Flap.StableMinutes = m.cfg.Flap.StableMinutes
+	}
+	normalizeFlap(&in.Flap)
+	in.Banner = strings.TrimSpace(in.Banner)
+	normalizeBannerLevel(&in)
+	if in.OverlapPolicy == "" {
+		in.OverlapPolicy = m.cfg.OverlapPolicy
+	}
+	normalizeOverlapPolicy(&in)
+	if in.DomainRatePerMin < 0 {
+		in.DomainRatePerMin = 0
+	}
+	if in.DomainRateLimits == nil {
+		in.DomainRateLimits = m.cfg.DomainRateLimits
+	} else if err := normalizeDomainRateLimits(in.DomainRateLimits); err != nil {
+		return err
+	}
+	if in.MaintenanceWindows == nil {
+		in.MaintenanceWindows = m.cfg.MaintenanceWindows
+	} else if err := NormalizeMaintenanceWindows(in.MaintenanceWindows); err != nil {
+		return err
+	}
+	if in.ListenAddr == "" {
+		in.ListenAddr = m.cfg.ListenAddr
+	} else if addr, err := NormalizeListenAddr(in.ListenAddr); err != nil {
+		return err
+	} else {
+		in.ListenAddr = addr
+	}
+	if in.RollupMode == "" {
+		in.RollupMode = m.cfg.RollupMode
+	}
+	if in.RollupDownPct <= 0 {
+		in.RollupDownPct = m.cfg.RollupDownPct
+	}
+	normalizeRollup(&in)
 
 	m.cfg.Interval = in.Interval
 	m.cfg.AlertThreshold = in.AlertThreshold
 	m.cfg.AlertCooldown = in.AlertCooldown
+	m.cfg.RetryCount = in.RetryCount
+	m.cfg.MaxRedirects = in.MaxRedirects
+	m.cfg.ConnectTimeoutSec = in.ConnectTimeoutSec
+	m.cfg.RequestTimeoutSec = in.RequestTimeoutSec
+	m.cfg.OverlapPolicy = in.OverlapPolicy
+	m.cfg.RollupMode = in.RollupMode
+	m.cfg.DomainRatePerMin = in.DomainRatePerMin
+	m.cfg.DomainRateLimits = in.DomainRateLimits
+	m.cfg.MaintenanceWindows = in.MaintenanceWindows
+	m.cfg.ListenAddr = in.ListenAddr
+	m.cfg.RollupDownPct = in.RollupDownPct
+	m.cfg.ManualCheckInterval = in.ManualCheckInterval
+	m.cfg.GroupAlertWindowSec = in.GroupAlertWindowSec
+	m.cfg.MinRecoverSec = in.MinRecoverSec
+	m.cfg.WarmupChecks = in.WarmupChecks
+	m.cfg.SlowAlertChecks = in.SlowAlertChecks
+	m.cfg.NotificationsEnabled = in.NotificationsEnabled
+	m.cfg.DNSFailThreshold = in.DNSFailThreshold
+	m.cfg.BackupIntervalHours = in.BackupIntervalHours
+	m.cfg.BackupKeep = in.BackupKeep
+	m.cfg.DiscoveryURL = in.DiscoveryURL
+	m.cfg.DiscoveryIntervalMin = in.DiscoveryIntervalMin
+	m.cfg.DNSCacheTTL = in.DNSCacheTTL
+	m.cfg.CertWarnDays = in.CertWarnDays
+	m.cfg.MaxConcurrency = in.MaxConcurrency
+	m.cfg.SparklineSize = in.SparklineSize
 	m.cfg.SMTP = in.SMTP
+	m.cfg.Webhook = in.Webhook
+	m.cfg.ErrorBudget = in.ErrorBudget
+	m.cfg.Flap = in.Flap
+	m.cfg.Banner = in.Banner
+	m.cfg.AlertTemplate = in.AlertTemplate
+	m.cfg.Vars = in.Vars
+	m.cfg.MaskSecrets = in.MaskSecrets
+	m.cfg.CaptureFailHeaders = in.CaptureFailHeaders
+	m.cfg.IncludeBodyPreview = in.IncludeBodyPreview
+	m.cfg.BodyPreviewBytes = in.BodyPreviewBytes
+	m.cfg.BannerLevel = in.BannerLevel
 	m.cfg.Analysis = in.Analysis
 
 	return m.saveLocked()
 }
 
-// saveLocked 将当前配置以JSON格式写入文件，调用前需持有锁。
+// saveLocked 将当前配置以JSON格式写入存储后端，调用前需持有锁。
 func (m *Manager) saveLocked() error {
 	// 🔥 核心：因为 m.cfg 在内存里是明文的（为了方便发送邮件），
 	// 在保存到硬盘前，我们“克隆”一份配置，并把克隆体里的密码加密。
 	saveCfg := m.cfg
 	saveCfg.SMTP.Password = encryptPassword(m.cfg.SMTP.Password)
 	saveCfg.Analysis.LLM.APIKey = encryptAPIKey(m.cfg.Analysis.LLM.APIKey)
+	saveCfg.Webhook.Secret = encryptWebhookSecret(m.cfg.Webhook.Secret)
+	saveCfg.Tasks = encryptTaskSecrets(m.cfg.Tasks)
 
 	data, err := json.MarshalIndent(saveCfg, "", "  ")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(m.path, data, 0644)
+	err = m.store.Save(data)
+	if errors.Is(err, ErrStoreConflict) {
+		// 其他实例已先保存：放弃本次修改并载入最新配置，由调用方提示用户刷新后重试
+		if reloadErr := m.reloadLocked(); reloadErr != nil {
+			return fmt.Errorf("%w，重新加载失败: %v", err, reloadErr)
+		}
+		return fmt.Errorf("%w，已载入最新配置，请刷新页面后重试", err)
+	}
+	return err
 }
 
-// 切换任务的标星状态，返回最新状态（true 表示已标星）
-func (m *Manager) ToggleStar(id int) (bool, error) {
+// reloadLocked 从存储后端重新读取配置替换内存中的配置，调用前需持有锁。
+func (m *Manager) reloadLocked() error {
+	data, err := m.store.Load()
+	if err != nil {
+		return err
+	}
+	cfg, err := decodeConfig(data)
+	if err != nil {
+		return err
+	}
+	m.cfg = cfg
+	return nil
+}
+
+// WatchStore 对共享存储（如数据库配置）定期检查其他实例是否已保存新配置，发现变化即重新加载，
+// 使各实例的任务与设置保持一致；本地文件存储无需检查，直接返回。ctx 结束时退出。
+func (m *Manager) WatchStore(ctx context.Context, interval time.Duration) {
+	shared, ok := m.store.(sharedStore)
+	if !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changed, err := shared.Changed()
+		if err != nil {
+			log.Printf("⚠️ 检查共享配置版本失败: %v", err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		m.mu.Lock()
+		err = m.reloadLocked()
+		m.mu.Unlock()
+		if err != nil {
+			log.Printf("⚠️ 重新加载共享配置失败: %v", err)
+			continue
+		}
+		log.Printf("🔄 检测到其他实例修改了配置，已重新加载")
+	}
+}
+
+// writeFileSync 写入文件并刷盘。
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LegacyStars 返回旧版本保存在任务配置中的标星任务 ID，不修改配置。
+func (m *Manager) LegacyStars() []int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []int
+	for _, t := range m.cfg.Tasks {
+		if t.Starred {
+			ids = append(ids, t.ID)
+		}
+	}
+	return ids
+}
+
+// ClearLegacyStars 清除指定任务在配置中的旧标星标记。调用方应在标星已写入新存储后再调用，
+// 避免写入失败时标星丢失；没有需要清除的标记时不写配置。
+func (m *Manager) ClearLegacyStars(ids []int) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	targets := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		targets[id] = true
+	}
+	changed := false
 	for i, t := range m.cfg.Tasks {
-		if t.ID == id {
-			m.cfg.Tasks[i].Starred = !t.Starred
-			return m.cfg.Tasks[i].Starred, m.saveLocked()
+		if t.Starred && targets[t.ID] {
+			m.cfg.Tasks[i].Starred = false
+			changed = true
 		}
 	}
-	return false, fmt.Errorf("未找到指定任务")
+	if !changed {
+		return nil
+	}
+	return m.saveLocked()
+}
+
+// SetArchived 归档或恢复指定任务，返回更新后的任务。
+func (m *Manager) SetArchived(id int, archived bool) (model.MonitorTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.cfg.Tasks {
+		if m.cfg.Tasks[i].ID == id {
+			m.cfg.Tasks[i].Archived = archived
+			return m.cfg.Tasks[i], m.saveLocked()
+		}
+	}
+	return model.MonitorTask{}, fmt.Errorf("未找到指定任务")
+}
+
+// SetPaused 暂停或恢复指定任务，返回更新后的任务。
+func (m *Manager) SetPaused(id int, paused bool) (model.MonitorTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.cfg.Tasks {
+		if m.cfg.Tasks[i].ID == id {
+			m.cfg.Tasks[i].Paused = paused
+			return m.cfg.Tasks[i], m.saveLocked()
+		}
+	}
+	return model.MonitorTask{}, fmt.Errorf("未找到指定任务")
+}
+
+// ArchivedTasks 返回所有已归档任务的副本。
+func (m *Manager) ArchivedTasks() []model.MonitorTask {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := []model.MonitorTask{}
+	for _, t := range m.cfg.Tasks {
+		if t.Archived {
+			out = append(out, t)
+		}
+	}
+	return out
 }
 
 func applyConfigDefaults(cfg *model.Config) {
+	if addr, err := NormalizeListenAddr(cfg.ListenAddr); err != nil {
+		log.Printf("⚠️ 监听地址 %q 无效（%v），已使用默认 %s", cfg.ListenAddr, err, DefaultListenAddr)
+		cfg.ListenAddr = DefaultListenAddr
+	} else {
+		cfg.ListenAddr = addr
+	}
 	if cfg.Interval <= 0 {
 		cfg.Interval = 5
 	}
+	if cfg.MinIntervalSec <= 0 {
+		cfg.MinIntervalSec = defaultMinIntervalSec
+	}
+	if cfg.BodyPreviewBytes <= 0 {
+		cfg.BodyPreviewBytes = defaultBodyPreviewBytes
+	}
+	cfg.BodyPreviewBytes = min(cfg.BodyPreviewBytes, maxBodyPreviewBytes)
+	if cfg.Interval < cfg.MinIntervalSec {
+		log.Printf("⚠️ 监控间隔 %d 秒低于下限 min_interval_sec=%d，已按 %d 秒执行", cfg.Interval, cfg.MinIntervalSec, cfg.MinIntervalSec)
+		cfg.Interval = cfg.MinIntervalSec
+	}
 	if cfg.AlertThreshold <= 0 {
 		cfg.AlertThreshold = 3
 	}
 	if cfg.AlertCooldown < 0 {
 		cfg.AlertCooldown = 60
 	}
+	if cfg.RetryCount < 0 {
+		cfg.RetryCount = 0
+	}
+	if cfg.MaxRedirects <= 0 {
+		cfg.MaxRedirects = 10
+	}
+	if cfg.MaxRedirects > maxRedirectsLimit {
+		cfg.MaxRedirects = maxRedirectsLimit
+	}
+	if cfg.ConnectTimeoutSec < 0 {
+		cfg.ConnectTimeoutSec = 0
+	}
+	cfg.RequestTimeoutSec = min(max(cfg.RequestTimeoutSec, 0), maxRequestTimeoutSec)
+	if cfg.ManualCheckInterval <= 0 {
+		cfg.ManualCheckInterval = 10
+	}
+	if cfg.GroupAlertWindowSec < 0 {
+		cfg.GroupAlertWindowSec = 0
+	}
+	if cfg.MinRecoverSec < 0 {
+		cfg.MinRecoverSec = 0
+	}
+	if cfg.WarmupChecks < 0 {
+		cfg.WarmupChecks = 0
+	}
+	if cfg.SlowAlertChecks < 0 {
+		cfg.SlowAlertChecks = 0
+	}
+	cfg.OnCheckScript = strings.TrimSpace(cfg.OnCheckScript)
+	if cfg.OnCheckScriptTimeoutSec <= 0 {
+		cfg.OnCheckScriptTimeoutSec = 10
+	}
+	if cfg.DNSFailThreshold < 0 {
+		cfg.DNSFailThreshold = 0
+	}
+	if cfg.MaxConcurrency < 0 {
+		cfg.MaxConcurrency = 0
+	}
+	if cfg.PerfWriteBatch < 0 {
+		cfg.PerfWriteBatch = 0
+	}
+	if cfg.PerfWriteBatch > maxPerfWriteBatch {
+		cfg.PerfWriteBatch = maxPerfWriteBatch
+	}
+	if cfg.SparklineSize <= 0 {
+		cfg.SparklineSize = 100
+	}
+	clampSparklineSize(cfg)
+	if cfg.DNSCacheTTL <= 0 {
+		cfg.DNSCacheTTL = 30
+	}
+	clampDNSCacheTTL(cfg)
+	if cfg.SMTP.RetryMax <= 0 {
+		cfg.SMTP.RetryMax = 5
+	}
+	if cfg.BackupIntervalHours < 0 {
+		cfg.BackupIntervalHours = 0
+	}
+	cfg.DiscoveryURL = strings.TrimSpace(cfg.DiscoveryURL)
+	if cfg.DiscoveryIntervalMin <= 0 {
+		cfg.DiscoveryIntervalMin = 10
+	}
+	if cfg.BackupKeep <= 0 {
+		cfg.BackupKeep = 7
+	}
+	if cfg.DBConnectAttempts <= 0 {
+		cfg.DBConnectAttempts = 3
+	}
+	if cfg.DBConnectInterval <= 0 {
+		cfg.DBConnectInterval = 1
+	}
 	if cfg.NextTaskID <= 0 {
 		maxID := 0
 		for _, t := range cfg.Tasks {
@@ -394,6 +1463,308 @@ func applyConfigDefaults(cfg *model.Config) {
 		cfg.NextTaskID = maxID + 1
 	}
 	normalizeAnalysisConfig(&cfg.Analysis)
+	normalizeErrorBudget(&cfg.ErrorBudget)
+	normalizeFlap(&cfg.Flap)
+	normalizeBannerLevel(cfg)
+	normalizeOverlapPolicy(cfg)
+	normalizeRollup(cfg)
+	if cfg.DomainRatePerMin < 0 {
+		cfg.DomainRatePerMin = 0
+	}
+	for domain, n := range cfg.DomainRateLimits {
+		if n < 0 {
+			cfg.DomainRateLimits[domain] = 0
+		}
+	}
+	_ = normalizeDomainRateLimits(cfg.DomainRateLimits)
+	dropInvalidMaintenanceWindows(cfg)
+}
+
+// NormalizeBurnRate 为多窗口错误率规则补齐默认窗口（5 分钟 / 60 分钟）与最少样本数，并校验阈值与窗口关系。
+func NormalizeBurnRate(r *model.BurnRateRule) error {
+	if r.ShortWindowMinutes <= 0 {
+		r.ShortWindowMinutes = 5
+	}
+	if r.LongWindowMinutes <= 0 {
+		r.LongWindowMinutes = 60
+	}
+	if r.MinSamples <= 0 {
+		r.MinSamples = 3
+	}
+	if r.LongWindowMinutes > 1440 {
+		return fmt.Errorf("多窗口告警的长窗口最长 1440 分钟")
+	}
+	if r.ShortWindowMinutes >= r.LongWindowMinutes {
+		return fmt.Errorf("多窗口告警的短窗口需小于长窗口")
+	}
+	if r.ShortMaxPct <= 0 || r.ShortMaxPct > 100 || r.LongMaxPct <= 0 || r.LongMaxPct > 100 {
+		return fmt.Errorf("多窗口告警的失败率阈值需在 0~100 之间")
+	}
+	return nil
+}
+
+// normalizeErrorBudget 为错误率规则补齐默认值：阈值 5%、窗口 60 分钟、最少 20 次检查。
+func normalizeErrorBudget(eb *model.ErrorBudgetConfig) {
+	if eb.MaxFailurePct <= 0 || eb.MaxFailurePct > 100 {
+		eb.MaxFailurePct = 5
+	}
+	if eb.WindowMinutes <= 0 {
+		eb.WindowMinutes = 60
+	}
+	if eb.WindowMinutes > 1440 {
+		eb.WindowMinutes = 1440
+	}
+	if eb.MinSamples <= 0 {
+		eb.MinSamples = 20
+	}
+}
+
+// normalizeFlap 为抖动检测补齐默认值：10 分钟内切换 5 次判定抖动，稳定 10 分钟后解除。
+func normalizeFlap(f *model.FlapConfig) {
+	if f.Transitions <= 1 {
+		f.Transitions = 5
+	}
+	if f.WindowMinutes <= 0 {
+		f.WindowMinutes = 10
+	}
+	if f.StableMinutes <= 0 {
+		f.StableMinutes = 10
+	}
+}
+
+// clampSparklineSize 将迷你趋势图缓冲区容量限制在 10~500 之间，控制内存占用。
+func clampSparklineSize(cfg *model.Config) {
+	if cfg.SparklineSize < 10 {
+		cfg.SparklineSize = 10
+	}
+	if cfg.SparklineSize > 500 {
+		cfg.SparklineSize = 500
+	}
+}
+
+// clampDNSCacheTTL 限制 DNS 缓存有效期不超过 300 秒，避免解析变更长时间不生效。
+func clampDNSCacheTTL(cfg *model.Config) {
+	if cfg.DNSCacheTTL > 300 {
+		cfg.DNSCacheTTL = 300
+	}
+}
+
+// normalizeOverlapPolicy 将批次重叠策略限定在 queue/skip 内，未知值回退为 queue。
+func normalizeOverlapPolicy(cfg *model.Config) {
+	if cfg.OverlapPolicy != "skip" {
+		cfg.OverlapPolicy = "queue"
+	}
+}
+
+// headerNamePattern 匹配合法的 HTTP 头名称（RFC 7230 token）。
+var headerNamePattern = regexp.MustCompile("^[!#$%&'*+\\-.^_`|~0-9A-Za-z]+$")
+
+// normalizeTaskType 将任务类型统一为小写（http 保存为空值），tcp/ping 任务不能使用只对 HTTP 有意义的选项。
+func normalizeTaskType(task *model.MonitorTask) error {
+	task.Type = strings.ToLower(strings.TrimSpace(task.Type))
+	if task.Type != model.TaskTypePing && task.PingCount != 0 {
+		return fmt.Errorf("ping_count 只适用于 ping 任务")
+	}
+	switch task.Type {
+	case "", model.TaskTypeHTTP:
+		task.Type = ""
+		return nil
+	case model.TaskTypeTCP, model.TaskTypePing:
+	default:
+		return fmt.Errorf("不支持的任务类型: %s（可选 http/tcp/ping）", task.Type)
+	}
+	if task.PingCount < 0 || task.PingCount > maxPingCount {
+		return fmt.Errorf("每次检查的 ping 次数应在 0~%d 之间", maxPingCount)
+	}
+	httpOnly := task.Method != "" || task.Body != "" || len(task.Headers) > 0 || task.ExpectedStatus != "" ||
+		task.MustContain != "" || task.MustNotContain != "" || task.BodyRegex != "" || task.ValidateJSON ||
+		len(task.RequiredKeys) > 0 || task.ExpectContentType != "" || len(task.SecurityHeaders) > 0 ||
+		len(task.RetryOnStatus) > 0 || task.RequireHTTPSRedirect || task.MaxRedirects > 0 || task.UseHTTP3 ||
+		task.ClientCertPath != "" || task.BasicAuthUser != ""
+	if httpOnly {
+		return fmt.Errorf("%s 任务只检查连通性，不支持请求方法、请求头、状态码与响应内容等 HTTP 选项", strings.ToUpper(task.Type))
+	}
+	return nil
+}
+
+// normalizeTaskMethod 将请求方法统一为大写并校验白名单；请求体仅允许随 POST/PUT 发送，
+// HEAD 没有响应体，不能与内容断言同时使用。
+func normalizeTaskMethod(task *model.MonitorTask) error {
+	task.Method = strings.ToUpper(strings.TrimSpace(task.Method))
+	if task.Method != "" && !allowedTaskMethods[task.Method] {
+		return fmt.Errorf("不支持的请求方法: %s（可选 GET/HEAD/POST/PUT）", task.Method)
+	}
+	if len(task.Body) > maxTaskBodyBytes {
+		return fmt.Errorf("请求体过长（最多 %d 字节）", maxTaskBodyBytes)
+	}
+	if task.Body != "" && task.Method != http.MethodPost && task.Method != http.MethodPut {
+		return fmt.Errorf("只有 POST/PUT 请求可以携带请求体")
+	}
+	if task.Method == http.MethodHead && (task.ValidateJSON || len(task.RequiredKeys) > 0 || task.BodyRegex != "" || task.MustContain != "" || task.MustNotContain != "") {
+		return fmt.Errorf("HEAD 请求没有响应体，不能与 JSON 校验、响应体正则或关键字规则同时使用")
+	}
+	return nil
+}
+
+// normalizeTaskHeaders 校验任务自定义请求头：名称需为合法的头名称并统一为规范大小写，值不能包含换行。
+func normalizeTaskHeaders(task *model.MonitorTask) error {
+	if len(task.Headers) == 0 {
+		task.Headers = nil
+		return nil
+	}
+	if len(task.Headers) > maxTaskHeaders {
+		return fmt.Errorf("自定义请求头过多（最多 %d 个）", maxTaskHeaders)
+	}
+	out := make(map[string]string, len(task.Headers))
+	for k, v := range task.Headers {
+		name := strings.TrimSpace(k)
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("请求头名称不合法: %q", k)
+		}
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("请求头 %s 的值不能包含换行", name)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if _, dup := out[name]; dup {
+			return fmt.Errorf("请求头重复: %s", name)
+		}
+		out[name] = strings.TrimSpace(v)
+	}
+	task.Headers = out
+	return nil
+}
+
+// normalizeBasicAuth 校验 Basic 认证凭据：用户名不能包含冒号与控制字符，设置了密码必须同时设置用户名，
+// 且不能与自定义的 Authorization 请求头同时使用。
+func normalizeBasicAuth(task *model.MonitorTask) error {
+	task.BasicAuthUser = strings.TrimSpace(task.BasicAuthUser)
+	if task.BasicAuthUser == "" {
+		if task.BasicAuthPass != "" {
+			return fmt.Errorf("设置了 Basic 认证密码时必须同时设置用户名")
+		}
+		return nil
+	}
+	if strings.ContainsFunc(task.BasicAuthUser, func(r rune) bool { return r == ':' || r < 0x20 || r == 0x7f }) {
+		return fmt.Errorf("Basic 认证用户名不能包含冒号或控制字符")
+	}
+	if strings.ContainsAny(task.BasicAuthPass, "\r\n") {
+		return fmt.Errorf("Basic 认证密码不能包含换行")
+	}
+	if _, ok := task.Headers["Authorization"]; ok {
+		return fmt.Errorf("Basic 认证与请求头 Authorization 不能同时设置")
+	}
+	return nil
+}
+
+// MaskTaskSecrets 返回隐去凭据的任务副本，供接口与页面展示：请求头的值替换为 MaskedHeaderValue，
+// Basic 认证密码清空（与 SMTP 密码一致，编辑时留空表示保持原密码）。
+func MaskTaskSecrets(task model.MonitorTask) model.MonitorTask {
+	task.Headers = MaskHeaders(task.Headers)
+	task.BasicAuthPass = ""
+	return task
+}
+
+// RestoreMaskedSecrets 将编辑时原样提交的占位值还原为已保存的真实值：*** 请求头沿用原值，
+// 留空的 Basic 认证密码沿用原密码；清空用户名时一并清除密码。
+func RestoreMaskedSecrets(in *model.MonitorTask, existing model.MonitorTask) {
+	RestoreMaskedHeaders(in.Headers, existing.Headers)
+	if strings.TrimSpace(in.BasicAuthUser) == "" {
+		in.BasicAuthPass = ""
+	} else if in.BasicAuthPass == "" {
+		in.BasicAuthPass = existing.BasicAuthPass
+	}
+}
+
+// MaskHeaders 返回以 MaskedHeaderValue 代替值的请求头副本，供接口与页面展示。
+func MaskHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return headers
+	}
+	out := make(map[string]string, len(headers))
+	for k := range headers {
+		out[k] = MaskedHeaderValue
+	}
+	return out
+}
+
+// RestoreMaskedHeaders 将编辑时原样提交的占位值还原为已保存的真实值，避免令牌被 *** 覆盖。
+func RestoreMaskedHeaders(in, existing map[string]string) {
+	for k, v := range in {
+		if v != MaskedHeaderValue {
+			continue
+		}
+		for ek, ev := range existing {
+			if strings.EqualFold(ek, k) {
+				in[k] = ev
+				break
+			}
+		}
+	}
+}
+
+// normalizeSecurityHeaders 规范化任务要求的安全头：头名称转为规范大小写，baseline 预设统一为小写，
+// 去掉空项与重复项，拒绝非法的头名称。
+func normalizeSecurityHeaders(task *model.MonitorTask) error {
+	if len(task.SecurityHeaders) == 0 {
+		task.SecurityHeaders = nil
+		return nil
+	}
+	out := make([]string, 0, len(task.SecurityHeaders))
+	seen := map[string]bool{}
+	for _, h := range task.SecurityHeaders {
+		h = strings.TrimSpace(h)
+		switch {
+		case h == "":
+			continue
+		case strings.EqualFold(h, "baseline"):
+			h = "baseline"
+		case !headerNamePattern.MatchString(h):
+			return fmt.Errorf("安全头名称不合法: %q", h)
+		default:
+			h = http.CanonicalHeaderKey(h)
+		}
+		if !seen[h] {
+			seen[h] = true
+			out = append(out, h)
+		}
+	}
+	task.SecurityHeaders = out
+	return nil
+}
+
+// normalizeDomainRateLimits 将按域名的速率设置的键统一为小写，拒绝负数速率。
+func normalizeDomainRateLimits(limits map[string]int) error {
+	for domain, n := range limits {
+		if n < 0 {
+			return fmt.Errorf("域名 %s 的检查速率不能为负数", domain)
+		}
+		if key := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), "."); key != domain {
+			delete(limits, domain)
+			limits[key] = n
+		}
+	}
+	return nil
+}
+
+// normalizeRollup 将状态汇总规则限定在 worst/weighted 内（未知值回退为 worst），
+// 故障阈值限定在 1~100，未设置时为 50。
+func normalizeRollup(cfg *model.Config) {
+	if cfg.RollupMode != "weighted" {
+		cfg.RollupMode = "worst"
+	}
+	if cfg.RollupDownPct <= 0 {
+		cfg.RollupDownPct = 50
+	}
+	cfg.RollupDownPct = min(cfg.RollupDownPct, 100)
+}
+
+// normalizeBannerLevel 将公告级别限定在 info/warn/danger 内，未知值回退为 info。
+func normalizeBannerLevel(cfg *model.Config) {
+	switch cfg.BannerLevel {
+	case "info", "warn", "danger":
+	default:
+		cfg.BannerLevel = "info"
+	}
 }
 
 func normalizeAnalysisConfig(analysis *model.AnalysisConfig) {
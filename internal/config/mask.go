@@ -0,0 +1,49 @@
+package config
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maskedValue 是脱敏后替换查询参数值的占位符。
+const maskedValue = "***"
+
+// MaskURL 返回去除 userinfo（user:pass@）并将查询参数值替换为 *** 的 URL，用于页面、接口与日志展示。
+// 参数名保留以便辨认；无法解析的地址原样返回。
+func MaskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || (u.User == nil && u.RawQuery == "") {
+		return raw
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		parts := strings.Split(u.RawQuery, "&")
+		for i, p := range parts {
+			if key, _, ok := strings.Cut(p, "="); ok {
+				parts[i] = key + "=" + maskedValue
+			}
+		}
+		u.RawQuery = strings.Join(parts, "&")
+	}
+	return u.String()
+}
+
+// MaskURLIn 将文本中出现的任务地址替换为脱敏形式，用于失败原因等可能内嵌完整 URL 的信息。
+// 除原始地址外，也会替换 Go 错误信息中常见的“密码已隐去”形式（user:***@ 与 user:xxxxx@）。
+func MaskURLIn(s, raw string) string {
+	if s == "" || raw == "" {
+		return s
+	}
+	masked := MaskURL(raw)
+	if masked == raw {
+		return s
+	}
+	s = strings.ReplaceAll(s, raw, masked)
+	if u, err := url.Parse(raw); err == nil && u.User != nil {
+		redacted := u.Redacted()
+		s = strings.ReplaceAll(s, redacted, masked)
+		// http.Client 报错时会把密码替换为 ***
+		s = strings.ReplaceAll(s, strings.Replace(redacted, ":xxxxx@", ":***@", 1), masked)
+	}
+	return s
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"monitor/internal/model"
+)
+
+// reconcileKey 返回任务在声明式同步中的匹配键：优先使用外部键，否则使用展开模板后的实际地址，
+// 使变量不同的同一模板不会被误判为重复，展开结果相同的任务也能互相匹配。
+func reconcileKey(t model.MonitorTask, global map[string]string) string {
+	if t.ExternalKey != "" {
+		return "key:" + t.ExternalKey
+	}
+	if expanded, err := ExpandURL(t.URL, global, t.Vars); err == nil {
+		return "url:" + expanded
+	}
+	return "url:" + t.URL
+}
+
+// sameTaskConfig 比较两个任务的配置是否一致，忽略 ID、标星、归档、暂停等非配置字段。
+// 通过 JSON 编码比较，使 nil 与空切片等等价写法不会被误判为变更。
+func sameTaskConfig(a, b model.MonitorTask) bool {
+	for _, t := range []*model.MonitorTask{&a, &b} {
+		t.ID = 0
+		t.Starred = false
+		t.Archived = false
+		t.Paused = false
+	}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// Reconcile 以 desired 为期望状态，同步由 source 管理的任务集合：
+// 缺失的新增、多余的删除、配置变化的更新，手动添加或其他来源管理的任务不受影响。
+// 重复提交相同的期望状态不会产生任何变更；dryRun 为 true 时只计算差异不落盘。
+// skipInvalid 为 false 时任一条目校验失败或重复即整体拒绝；为 true 时（任务发现）跳过该条目并记入 Skipped，
+// 其余条目照常同步，与被跳过条目匹配的现有任务予以保留，不会因一次坏数据被删除。
+func (m *Manager) Reconcile(source string, desired []model.MonitorTask, dryRun, skipInvalid bool) (model.ReconcileResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := model.ReconcileResult{
+		Added:   []model.MonitorTask{},
+		Updated: []model.MonitorTask{},
+		Removed: []model.MonitorTask{},
+	}
+	if source == "" {
+		return result, fmt.Errorf("缺少同步来源标识")
+	}
+
+	// 1. 规范化期望状态，并检查匹配键冲突
+	want := make(map[string]model.MonitorTask, len(desired))
+	order := make([]string, 0, len(desired))
+	keep := make(map[string]bool) // 宽松模式下被跳过条目的匹配键，对应的现有任务保留不动
+	for _, in := range desired {
+		raw := in
+		key, err := m.normalizeDesiredLocked(&in, source)
+		if err == nil {
+			if _, dup := want[key]; dup {
+				err = fmt.Errorf("期望状态中存在重复的任务: %s", key)
+			}
+		}
+		if err != nil {
+			if !skipInvalid {
+				return result, err
+			}
+			result.Skipped = append(result.Skipped, model.ReconcileSkip{Name: raw.Name, URL: raw.URL, Error: err.Error()})
+			if key == "" {
+				key = reconcileKey(raw, m.cfg.Vars)
+			}
+			keep[key] = true
+			continue
+		}
+		want[key] = in
+		order = append(order, key)
+	}
+	if skipInvalid && len(want) == 0 && len(desired) > 0 {
+		return result, fmt.Errorf("全部 %d 个条目均校验失败，为防止误删任务本次不同步", len(desired))
+	}
+
+	// 2. 对比现有同来源任务：更新或删除
+	seen := make(map[string]bool, len(want))
+	tasks := make([]model.MonitorTask, 0, len(m.cfg.Tasks)+len(desired))
+	for _, t := range m.cfg.Tasks {
+		if t.ManagedBy != source {
+			tasks = append(tasks, t)
+			continue
+		}
+		key := reconcileKey(t, m.cfg.Vars)
+		in, ok := want[key]
+		if !ok && keep[key] {
+			tasks = append(tasks, t)
+			continue
+		}
+		if !ok || seen[key] {
+			result.Removed = append(result.Removed, t)
+			continue
+		}
+		seen[key] = true
+		in.ID = t.ID
+		in.Starred = t.Starred
+		in.Archived = t.Archived
+		in.Paused = t.Paused
+		if sameTaskConfig(t, in) {
+			result.Unchanged++
+			tasks = append(tasks, t)
+			continue
+		}
+		result.Updated = append(result.Updated, in)
+		tasks = append(tasks, in)
+	}
+
+	// 3. 新增缺失任务，ID 由发号器分配
+	nextID := m.cfg.NextTaskID
+	for _, key := range order {
+		if seen[key] {
+			continue
+		}
+		in := want[key]
+		in.ID = nextID
+		in.Starred = false
+		in.Archived = false
+		in.Paused = false
+		nextID++
+		result.Added = append(result.Added, in)
+		tasks = append(tasks, in)
+	}
+
+	if dryRun || (len(result.Added) == 0 && len(result.Updated) == 0 && len(result.Removed) == 0) {
+		return result, nil
+	}
+	m.cfg.Tasks = tasks
+	m.cfg.NextTaskID = nextID
+	return result, m.saveLocked()
+}
+
+// normalizeDesiredLocked 规范化并校验一条期望任务，返回其匹配键；出错时返回的匹配键可能为空。
+func (m *Manager) normalizeDesiredLocked(in *model.MonitorTask, source string) (string, error) {
+	name, rawURL, err := NormalizeAndValidateTaskInput(in.Name, in.URL, in.Type, m.cfg.Vars, in.Vars)
+	if err != nil {
+		return "", fmt.Errorf("任务 %q: %v", in.Name, err)
+	}
+	in.Name, in.URL = name, rawURL
+	key := reconcileKey(*in, m.cfg.Vars)
+	if err := ValidateTaskOptions(in, m.cfg.Vars); err != nil {
+		return key, fmt.Errorf("任务 %q: %v", in.Name, err)
+	}
+	in.ManagedBy = source
+	return key, nil
+}
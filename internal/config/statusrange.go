@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StatusRange 是一段闭区间的 HTTP 状态码，单个状态码的 Min 与 Max 相同。
+type StatusRange struct {
+	Min, Max int
+}
+
+// StatusRanges 是任务 expected_status 解析后的状态码集合。
+type StatusRanges []StatusRange
+
+// Contains 判断状态码是否落在任一区间内。
+func (r StatusRanges) Contains(code int) bool {
+	for _, s := range r {
+		if code >= s.Min && code <= s.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回规范化的写法，如 "200-299,401"。
+func (r StatusRanges) String() string {
+	parts := make([]string, len(r))
+	for i, s := range r {
+		if s.Min == s.Max {
+			parts[i] = strconv.Itoa(s.Min)
+		} else {
+			parts[i] = fmt.Sprintf("%d-%d", s.Min, s.Max)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseStatusRanges 解析逗号分隔的状态码与区间（如 "200-299,401"），状态码需在 100~599 之间。
+func ParseStatusRanges(spec string) (StatusRanges, error) {
+	var out StatusRanges
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("期望状态码不合法: %q", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil {
+				return nil, fmt.Errorf("期望状态码不合法: %q", part)
+			}
+		}
+		if from < 100 || to > 599 || from > to {
+			return nil, fmt.Errorf("期望状态码需在 100~599 之间且区间起点不大于终点: %q", part)
+		}
+		out = append(out, StatusRange{Min: from, Max: to})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("期望状态码不能为空")
+	}
+	return out, nil
+}
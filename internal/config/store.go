@@ -0,0 +1,69 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Store 是配置的字节存储后端。Manager 负责加解密、规范化与加锁，Store 只读写序列化后的 JSON，
+// 默认使用本地文件（FileStore），多实例部署可换成共享数据库等实现。
+type Store interface {
+	// Load 读取配置内容；尚无配置时返回的错误应满足 errors.Is(err, os.ErrNotExist)。
+	Load() ([]byte, error)
+	// Save 完整覆盖保存配置内容。
+	Save(data []byte) error
+}
+
+// ErrStoreConflict 表示保存时发现存储中的配置已被其他实例修改，本次写入未生效。
+var ErrStoreConflict = errors.New("配置已被其他实例修改")
+
+// sharedStore 是可被多个实例同时使用的存储后端：Save 仅在存储内容仍是本实例上次读取或写入的版本时生效，
+// 否则返回 ErrStoreConflict；Changed 报告其他实例是否已写入新版本，供 Manager 定期重新加载。
+type sharedStore interface {
+	Changed() (bool, error)
+}
+
+// recoverableStore 是支持损坏恢复的存储后端：可隔离损坏的数据并读取上一份备份。
+type recoverableStore interface {
+	Quarantine() (string, error)
+	LoadBackup() ([]byte, string, error)
+}
+
+// FileStore 以本地 JSON 文件保存配置，写入时保留上一份可解析的配置为 .bak。
+type FileStore struct {
+	Path string
+}
+
+// Load 读取配置文件。
+func (f *FileStore) Load() ([]byte, error) {
+	return os.ReadFile(f.Path)
+}
+
+// Save 先写临时文件再原子替换，避免写到一半断电留下截断的配置；
+// 替换前把上一份可解析的配置保留为 .bak，供 LoadOrDefault 损坏恢复使用。
+func (f *FileStore) Save(data []byte) error {
+	tmpPath := f.Path + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		return err
+	}
+	if prev, err := os.ReadFile(f.Path); err == nil && json.Valid(prev) {
+		_ = os.WriteFile(f.Path+".bak", prev, 0644)
+	}
+	return os.Rename(tmpPath, f.Path)
+}
+
+// Quarantine 将损坏的配置文件另存为 .corrupt-<时间戳>，返回新路径。
+func (f *FileStore) Quarantine() (string, error) {
+	corruptPath := fmt.Sprintf("%s.corrupt-%s", f.Path, time.Now().Format("20060102-150405"))
+	return corruptPath, os.Rename(f.Path, corruptPath)
+}
+
+// LoadBackup 读取 .bak 备份，返回内容与备份路径。
+func (f *FileStore) LoadBackup() ([]byte, string, error) {
+	backupPath := f.Path + ".bak"
+	data, err := os.ReadFile(backupPath)
+	return data, backupPath, err
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"monitor/internal/model"
+)
+
+var urlTemplateCache sync.Map // map[string]*template.Template
+
+// IsURLTemplate 判断任务地址是否包含模板语法（如 https://{{.host}}/health）。
+func IsURLTemplate(rawURL string) bool {
+	return strings.Contains(rawURL, "{{")
+}
+
+// parseURLTemplate 返回地址模板的解析结果，首次使用时解析并缓存；引用未定义的变量时展开失败。
+func parseURLTemplate(rawURL string) (*template.Template, error) {
+	if t, ok := urlTemplateCache.Load(rawURL); ok {
+		return t.(*template.Template), nil
+	}
+	t, err := template.New("url").Option("missingkey=error").Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	urlTemplateCache.Store(rawURL, t)
+	return t, nil
+}
+
+// ExpandURL 用变量展开任务地址模板，任务变量覆盖同名的全局变量；不含模板语法的地址原样返回。
+func ExpandURL(rawURL string, global, vars map[string]string) (string, error) {
+	if !IsURLTemplate(rawURL) {
+		return rawURL, nil
+	}
+	t, err := parseURLTemplate(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("URL 模板语法错误: %v", err)
+	}
+	data := make(map[string]string, len(global)+len(vars))
+	for k, v := range global {
+		data[k] = v
+	}
+	for k, v := range vars {
+		data[k] = v
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("URL 模板展开失败: %v", err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// validateExpandedTarget 按普通任务地址的规则校验模板展开结果：http 任务要求其自带 http:// 或 https://，
+// tcp 任务要求展开为 host:port，ping 任务要求展开为主机名或 IP。
+func validateExpandedTarget(expanded, taskType string) error {
+	if t := strings.ToLower(taskType); t == model.TaskTypeTCP || t == model.TaskTypePing {
+		normalized, err := taskTargetNormalizer(t)(expanded)
+		if err != nil {
+			return fmt.Errorf("模板展开为 %s: %v", expanded, err)
+		}
+		if normalized != expanded {
+			return fmt.Errorf("模板展开后的地址不是规范写法: %s（应为 %s）", expanded, normalized)
+		}
+		return nil
+	}
+	normalized, err := normalizeTaskURL(expanded)
+	if err != nil {
+		return fmt.Errorf("模板展开为 %s: %v", expanded, err)
+	}
+	if normalized != expanded {
+		return fmt.Errorf("模板展开后的地址需以 http:// 或 https:// 开头: %s", expanded)
+	}
+	return nil
+}
+
+// validateTemplatedTasks 校验全局变量由 old 变更为 global 后模板任务仍能展开为合法地址，避免改错变量导致批量任务失效；
+// 变更前就无法展开的任务不阻止保存。
+func validateTemplatedTasks(tasks []model.MonitorTask, old, global map[string]string) error {
+	for _, t := range tasks {
+		if !IsURLTemplate(t.URL) {
+			continue
+		}
+		if _, err := ExpandURL(t.URL, old, t.Vars); err != nil {
+			continue
+		}
+		expanded, err := ExpandURL(t.URL, global, t.Vars)
+		if err == nil {
+			err = validateExpandedTarget(expanded, t.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("任务 %q: %v", t.Name, err)
+		}
+	}
+	return nil
+}
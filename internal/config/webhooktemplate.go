@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+
+	"monitor/internal/model"
+)
+
+// ParseWebhookTemplate 解析 Webhook 请求体模板（text/template）。模板中可用 json 函数把值编码为 JSON 字面量，
+// 如 Slack 的 {"text": {{json .Message}}}、Discord 的 {"content": {{json .Message}}}。
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	t, err := template.New("webhook").Option("missingkey=error").Funcs(template.FuncMap{"json": jsonLiteral}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("Webhook 模板解析失败: %w", err)
+	}
+	return t, nil
+}
+
+// ValidateWebhookTemplate 解析模板并用示例载荷试渲染一次，字段名拼写错误等执行期错误在保存时即可发现，
+// 而不是等到真实告警时才回退为默认 JSON（Slack、Discord 等固定格式的入站 Webhook 会拒收）。
+func ValidateWebhookTemplate(text string) error {
+	t, err := ParseWebhookTemplate(text)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(io.Discard, sampleWebhookPayload); err != nil {
+		return fmt.Errorf("Webhook 模板渲染失败: %w", err)
+	}
+	return nil
+}
+
+// sampleWebhookPayload 是校验 Webhook 模板时使用的示例载荷。
+var sampleWebhookPayload = model.WebhookPayload{
+	Event:      "alert",
+	Subject:    "🔥 [报警] 示例服务 宕机 (累积失败3次)",
+	TaskID:     1,
+	TaskName:   "示例服务",
+	URL:        "https://api.example.com/health",
+	Status:     "故障",
+	StatusCode: 503,
+	FailCount:  3,
+	Message:    "服务 [示例服务] 确认故障! (连续失败3次, 响应码:503)",
+	Time:       "2006-01-02 15:04:05",
+	DedupKey:   "0123456789abcdef0123456789abcdef",
+}
+
+func jsonLiteral(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
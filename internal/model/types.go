@@ -8,13 +8,112 @@ import (
 
 // Config 表示系统的完整配置，包含监控间隔、告警阈值、SMTP 设置以及监控任务列表。
 type Config struct {
-	Interval       int            `json:"interval"`
-	AlertThreshold int            `json:"alert_threshold"`
-	AlertCooldown  int            `json:"alert_cooldown"`
-	NextTaskID     int            `json:"next_task_id"` // 全局自增发号器
-	SMTP           SMTPConfig     `json:"smtp"`
-	Analysis       AnalysisConfig `json:"analysis"`
-	Tasks          []MonitorTask  `json:"tasks"`
+	Interval             int               `json:"interval"`
+	AlertThreshold       int               `json:"alert_threshold"`
+	AlertCooldown        int               `json:"alert_cooldown"`
+	MaxRedirects         int               `json:"max_redirects"`          // 探测最多跟随的跳转次数，超出判定失败（多为重定向循环），任务可单独覆盖
+	ConnectTimeoutSec    int               `json:"connect_timeout_sec"`    // 建立 TCP 连接的超时（秒），短于整体超时可快速判定主机不可达，0 表示不单独限制
+	RetryCount           int               `json:"retry_count"`            // 检查失败时的最大重试次数
+	OverlapPolicy        string            `json:"overlap_policy"`         // 上一批次未结束时新触发的处理方式：queue（排队，默认）/ skip（跳过）
+	SparklineSize        int               `json:"sparkline_size"`         // 每个任务在内存中保留的最近检查结果数（迷你趋势图），范围 10~500
+	MaxConcurrency       int               `json:"max_concurrency"`        // 单批次最多同时进行的检查数，0 表示不限制
+	PerfWriteBatch       int               `json:"perf_write_batch"`       // 性能日志异步批量写入的每批条数，0 表示逐条同步写入
+	CertWarnDays         int               `json:"cert_warn_days"`         // HTTPS 证书剩余天数低于该值时预警，0 表示关闭
+	DNSCacheTTL          int               `json:"dns_cache_ttl"`          // 探测用 DNS 缓存有效期（秒），上限 300 以便及时感知解析变更
+	DNSFailThreshold     int               `json:"dns_fail_threshold"`     // DNS 解析失败连续多少次才告警（软失败），不大于 alert_threshold 时与普通失败一致
+	NotificationsEnabled bool              `json:"notifications_enabled"`  // 通知总开关：关闭后邮件与 Webhook 一律不发送，事件仍照常记录（适用于大型计划维护）
+	WarmupChecks         int               `json:"warmup_checks"`          // 预热检查次数：新任务或重启后的前 N 次检查失败不计入告警阈值，0 表示关闭
+	SlowAlertChecks      int               `json:"slow_alert_checks"`      // 连续缓慢多少次发送“响应缓慢”预警，随后宕机时告警会引用该预警，0 表示关闭
+	MinRecoverSec        int               `json:"min_recover_sec"`        // 宕机任务需持续正常多少秒才发送恢复通知（防抖），0 表示立即
+	GroupAlertWindowSec  int               `json:"group_alert_window_sec"` // 告警邮件合并窗口（秒），窗口内的多条告警合并为一封，0 表示逐条发送
+	ManualCheckInterval  int               `json:"manual_check_interval"`  // 同一任务两次手动检查的最小间隔（秒），保护脆弱的目标
+	DBConnectAttempts    int               `json:"db_connect_attempts"`    // 启动时连接数据库的最大尝试次数，适配数据库与监控同时启动的容器编排
+	DBConnectInterval    int               `json:"db_connect_interval"`    // 启动时两次连接数据库尝试之间的间隔（秒）
+	BackupIntervalHours  int               `json:"backup_interval_hours"`  // 自动备份配置与数据库的间隔（小时），0 表示关闭
+	BackupKeep           int               `json:"backup_keep"`            // backup 目录最多保留的备份批次，超出时删除最旧的
+	NextTaskID           int               `json:"next_task_id"`           // 全局自增发号器
+	Banner               string            `json:"banner"`                 // 看板顶部公告（如维护通知），为空不展示
+	BannerLevel          string            `json:"banner_level"`           // 公告级别：info / warn / danger
+	MaskSecrets          bool              `json:"mask_secrets"`           // 展示任务地址时去除 userinfo 并隐藏查询参数值，避免凭据经看板/接口/事件泄露
+	CaptureFailHeaders   bool              `json:"capture_fail_headers"`   // 检查失败时保存（脱敏、有界的）响应头快照，随宕机告警事件入库供事后复盘
+	AlertTemplate        string            `json:"alert_template"`         // 宕机告警邮件正文模板（text/template），为空使用默认正文
+	SMTP                 SMTPConfig        `json:"smtp"`
+	Webhook              WebhookConfig     `json:"webhook"`
+	ErrorBudget          ErrorBudgetConfig `json:"error_budget"`
+	Flap                 FlapConfig        `json:"flap"`
+	Analysis             AnalysisConfig    `json:"analysis"`
+	Vars                 map[string]string `json:"vars,omitempty"` // 全局 URL 模板变量，任务地址可写作 https://{{.host}}/health，任务级 vars 覆盖同名变量
+	Tasks                []MonitorTask     `json:"tasks"`
+
+	// OnCheckScript 为每次定时检查完成后调用的外部脚本路径，检查结果以 JSON 写入其标准输入。
+	// 脚本以监控进程的权限运行，因此只能在配置文件中设置（系统设置接口不会修改它），
+	// 且仅在设置环境变量 MONITOR_ALLOW_CHECK_SCRIPT=1 时生效；默认关闭。
+	OnCheckScript string `json:"on_check_script,omitempty"`
+	// OnCheckScriptTimeoutSec 为脚本单次执行的超时（秒），超时后强制结束，默认 10
+	OnCheckScriptTimeoutSec int `json:"on_check_script_timeout_sec,omitempty"`
+
+	// DiscoveryURL 为任务发现源地址（JSON 列表或 sitemap.xml），定期拉取并同步为 managed_by=discovery 的任务，为空表示关闭
+	DiscoveryURL string `json:"discovery_url,omitempty"`
+	// DiscoveryIntervalMin 为任务发现的拉取间隔（分钟），默认 10
+	DiscoveryIntervalMin int `json:"discovery_interval_min,omitempty"`
+
+	// MinIntervalSec 为监控间隔允许的下限（秒），默认 5，防止误配置的高频探测压垮目标与监控本身。
+	// 只能在配置文件中调整（系统设置接口不会修改它），确需秒级探测自有服务时可调低至 1。
+	MinIntervalSec int `json:"min_interval_sec,omitempty"`
+
+	// AlertLogPath 为 JSON Lines 告警日志路径，每次宕机告警与恢复追加一行，供 SIEM 采集；为空表示关闭。
+	// 只能在配置文件中设置，收到 SIGHUP 时重新打开文件以配合日志轮转。
+	AlertLogPath string `json:"alert_log_path,omitempty"`
+
+	// IncludeBodyPreview 为 true 时，内容断言失败的告警附带响应体开头的预览（已脱敏），随事件入库并写入邮件与 Webhook
+	IncludeBodyPreview bool `json:"include_body_preview,omitempty"`
+	// BodyPreviewBytes 为响应体预览的最大长度（字节），默认 512，上限 4096
+	BodyPreviewBytes int `json:"body_preview_bytes,omitempty"`
+
+	// RollupMode 为状态汇总 (/api/tree) 的规则：worst（默认，任一下级异常即降级）或 weighted（按任务权重计算健康度）
+	RollupMode string `json:"rollup_mode,omitempty"`
+	// RollupDownPct 为加权模式下判定为故障的健康度阈值（百分比），低于该值为 down，默认 50
+	RollupDownPct int `json:"rollup_down_pct,omitempty"`
+
+	// DomainRatePerMin 为同一可注册域名（如 *.example.com）每分钟最多的定时检查次数，超出的任务推迟到后续批次，0 表示不限制
+	DomainRatePerMin int `json:"domain_rate_per_min,omitempty"`
+	// DomainRateLimits 为个别域名单独设置的每分钟检查次数（键为可注册域名），优先于 DomainRatePerMin，0 表示该域名不限制
+	DomainRateLimits map[string]int `json:"domain_rate_limits,omitempty"`
+
+	// TemplatePath 为外部看板页面模板（html/template）路径，启动时解析，失败时回退到内置模板；
+	// 只能在配置文件中设置，修改后需重启生效
+	TemplatePath string `json:"template_path,omitempty"`
+
+	// RequestTimeoutSec 为探测请求的默认超时（秒），任务未设置 timeout 时使用；为 0 时取 5 秒（不超过监控间隔）
+	RequestTimeoutSec int `json:"request_timeout_sec,omitempty"`
+
+	// MaintenanceWindows 为计划维护时段，期间检查照常执行，但不发送告警与恢复通知
+	MaintenanceWindows []MaintenanceWindow `json:"maintenance_windows,omitempty"`
+
+	// ListenAddr 为管理后台监听地址（如 ":9090"、"127.0.0.1:9191"），修改后需重启生效；环境变量 MONITOR_ADDR 优先
+	ListenAddr string `json:"listen_addr,omitempty"`
+
+	// TLS 控制管理后台是否以 HTTPS 提供服务，修改后需重启生效
+	TLS TLSConfig `json:"tls"`
+}
+
+// TLSConfig 是管理后台的 HTTPS 配置。启用但未提供证书时自动生成仅适用于本机访问的自签名证书。
+type TLSConfig struct {
+	Enabled  bool   `json:"enabled"`
+	CertFile string `json:"cert_file,omitempty"` // PEM 证书（可含中间证书链）路径，需与 key_file 成对配置
+	KeyFile  string `json:"key_file,omitempty"`  // PEM 私钥路径
+}
+
+// MaintenanceWindow 定义一个计划维护时段。每周重复的窗口用 Start/End（"HH:MM"，End 早于 Start 表示跨午夜）
+// 与可选的 Weekdays；一次性窗口用 From/Until（"2006-01-02 15:04"，本地时区）。TaskIDs 为空时对所有任务生效。
+type MaintenanceWindow struct {
+	Name     string `json:"name"`
+	Weekdays []int  `json:"weekdays,omitempty"` // 0=周日 … 6=周六，为空表示每天
+	Start    string `json:"start,omitempty"`
+	End      string `json:"end,omitempty"`
+	From     string `json:"from,omitempty"`
+	Until    string `json:"until,omitempty"`
+	TaskIDs  []int  `json:"task_ids,omitempty"`
 }
 
 // SMTPConfig 包含邮件服务器连接信息及收件人地址。
@@ -24,7 +123,47 @@ type SMTPConfig struct {
 	Port     int    `json:"port"`
 	Username string `json:"username"`
 	Password string `json:"password"`
-	To       string `json:"to"` // 收件人邮箱，多个可用逗号分隔
+	To       string `json:"to"`        // 收件人邮箱，多个可用逗号分隔
+	RetryMax int    `json:"retry_max"` // 告警邮件发送失败后的最大重试次数
+}
+
+// WebhookConfig 定义告警/恢复事件的 Webhook 推送地址及 HMAC 签名密钥。
+// Secret 非空时，每次推送都会携带 X-Timestamp 与 X-Signature 请求头，供接收方校验来源。
+type WebhookConfig struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`
+	Secret  string `json:"secret"` // 签名共享密钥，落盘时加密
+	// Template 为请求体模板（text/template），为空时推送默认 JSON 载荷；可用 json 函数编码字段，
+	// 如 Slack 的 {"text": {{json .Message}}}
+	Template string `json:"template,omitempty"`
+}
+
+// ErrorBudgetConfig 定义基于错误率的告警规则（SLO 错误预算消耗）：
+// 窗口内失败率超过阈值即告警，用于发现从未触发连续失败阈值的间歇性抖动。
+type ErrorBudgetConfig struct {
+	Enabled       bool    `json:"enabled"`
+	MaxFailurePct float64 `json:"max_failure_pct"` // 失败率阈值（百分比），如 5 表示 5%
+	WindowMinutes int     `json:"window_minutes"`  // 统计窗口（分钟），最长 1440
+	MinSamples    int     `json:"min_samples"`     // 窗口内检查次数不足时不评估，避免样本过少误报
+}
+
+// FlapConfig 定义抖动检测规则：窗口内成功/失败切换次数达到阈值即判定为抖动，
+// 抖动期间暂停常规告警，只发送一次抖动通知，稳定一段时间后自动解除。
+type FlapConfig struct {
+	Enabled       bool `json:"enabled"`
+	Transitions   int  `json:"transitions"`    // 判定抖动的切换次数阈值
+	WindowMinutes int  `json:"window_minutes"` // 统计切换次数的窗口（分钟）
+	StableMinutes int  `json:"stable_minutes"` // 最近一次切换后稳定多久解除抖动（分钟）
+}
+
+// BurnRateRule 定义任务级的多窗口错误率告警（参考 SRE multi-window burn rate）：
+// 短窗口与长窗口的失败率同时超过各自阈值才告警，过滤短暂尖刺，同时对持续消耗错误预算的故障保持敏感。
+type BurnRateRule struct {
+	ShortWindowMinutes int     `json:"short_window_minutes"` // 短窗口（分钟），默认 5
+	ShortMaxPct        float64 `json:"short_max_pct"`        // 短窗口失败率阈值（百分比）
+	LongWindowMinutes  int     `json:"long_window_minutes"`  // 长窗口（分钟），默认 60，最长 1440
+	LongMaxPct         float64 `json:"long_max_pct"`         // 长窗口失败率阈值（百分比）
+	MinSamples         int     `json:"min_samples"`          // 每个窗口至少需要的检查次数，默认 3
 }
 
 // AnalysisConfig 定义稳定性智能分析模块的开关、缓存与 LLM 增强配置。
@@ -46,44 +185,253 @@ type LLMConfig struct {
 	TimeoutSeconds int    `json:"timeout_seconds"`
 }
 
+// 任务类型取值，见 MonitorTask.Type。
+const (
+	TaskTypeHTTP = "http"
+	TaskTypeTCP  = "tcp"
+	TaskTypePing = "ping"
+)
+
 // MonitorResult 用于 Web 页面展示的监控结果视图模型，聚合了最新检查信息和历史状态。
 type MonitorTask struct {
-	ID      int    `json:"id"`
-	Name    string `json:"name"`
-	URL     string `json:"url"`
-	Starred bool   `json:"starred"` // 是否标星置顶
+	ID                   int      `json:"id"`
+	Name                 string   `json:"name"`
+	URL                  string   `json:"url"`
+	Starred              bool     `json:"starred,omitempty"`                // 旧版本保存在配置中的标星状态，启动时迁移到数据库后清空；标星现由数据库 task_stars 表保存
+	RetryOnStatus        []int    `json:"retry_on_status,omitempty"`        // 命中这些状态码时先重试，重试耗尽仍命中才判定失败
+	Archived             bool     `json:"archived,omitempty"`               // 已归档：保留配置与历史，但不参与监控与展示
+	SourceIP             string   `json:"source_ip,omitempty"`              // 指定出口源地址，用于验证多网卡主机上特定网络路径的可达性
+	InvertStatus         bool     `json:"invert_status,omitempty"`          // 反向监控：目标应不可访问，可访问时告警（如不应公开的调试端点）
+	ExternalKey          string   `json:"external_key,omitempty"`           // 外部系统（Terraform/Ansible 等）的任务标识，声明式同步时优先按它匹配
+	ManagedBy            string   `json:"managed_by,omitempty"`             // 管理来源（如 "provision"），为空表示手动添加
+	ValidateJSON         bool     `json:"validate_json,omitempty"`          // 校验响应体为合法 JSON（仅对 JSON 类型的响应生效）
+	RequiredKeys         []string `json:"required_keys,omitempty"`          // 开启 JSON 校验时，响应顶层对象必须包含的键
+	BodyRegex            string   `json:"body_regex,omitempty"`             // 响应体必须匹配的正则表达式（RE2 语法，最多读取 1MB），保存时校验语法
+	ClientCertPath       string   `json:"client_cert_path,omitempty"`       // 双向 TLS 客户端证书（PEM）路径，需与私钥成对配置
+	ClientKeyPath        string   `json:"client_key_path,omitempty"`        // 双向 TLS 客户端私钥（PEM）路径
+	RequireHTTPSRedirect bool     `json:"require_https_redirect,omitempty"` // 要求 http:// 地址以 3xx 跳转到 https://（不跟随跳转）
+	MaxRedirects         int      `json:"max_redirects,omitempty"`          // 覆盖全局最多跟随的跳转次数，0 表示沿用全局
+	MaxResponseMS        int64    `json:"max_response_ms,omitempty"`        // 可接受的最长响应时间（毫秒），请求完成但超过该值即判定失败，0 表示不限制
+	Priority             int      `json:"priority,omitempty"`               // 检查优先级，数值越大越先派发；启用并发上限时高优先级任务优先拿到检查名额
+	Manual               bool     `json:"manual,omitempty"`                 // 仅按需检查：不参与定时轮询，只通过 /api/task/check（或批量检查接口）触发
+	Tenant               string   `json:"tenant,omitempty"`                 // 所属项目/租户，用于在同一实例中按项目筛选结果与日志，为空表示默认项目
+	CertExpiryAlertDays  *int     `json:"cert_expiry_alert_days,omitempty"` // 证书到期预警天数，覆盖全局 cert_warn_days；0 表示不预警（如长期自签证书），未设置时沿用全局
+
+	// Vars 为任务级 URL 模板变量，覆盖同名的全局 vars
+	Vars map[string]string `json:"vars,omitempty"`
+	// BurnRate 为多窗口错误率告警规则，未设置时不启用
+	BurnRate *BurnRateRule `json:"burn_rate,omitempty"`
+
+	// AlertTo 为该任务告警邮件的收件人（逗号分隔），覆盖全局 smtp.to
+	AlertTo string `json:"alert_to,omitempty"`
+	// AlertWebhook 为该任务专用的 Webhook 地址，覆盖全局地址（沿用全局签名密钥），全局 Webhook 未启用时也会推送
+	AlertWebhook string `json:"alert_webhook,omitempty"`
+	// UseHTTP3 为 true 时经 QUIC (HTTP/3) 检查，用于确认 h3 路径可用；仅支持 https 地址，需以 -tags http3 构建
+	UseHTTP3 bool `json:"use_http3,omitempty"`
+	// SLATargetMS 为响应时间 SLA 目标（毫秒），结果中据此给出是否达标，0 表示不设目标；与判定故障的 max_response_ms 相互独立
+	SLATargetMS int64 `json:"sla_target_ms,omitempty"`
+	// ExpectContentType 为响应 Content-Type 必须匹配的前缀（忽略 charset 等参数，大小写不敏感），如 "application/json"
+	ExpectContentType string `json:"expect_content_type,omitempty"`
+	// DependsOn 为上游任务 ID（如网关），上游故障期间本任务的宕机告警只记录不通知，0 表示无依赖
+	DependsOn int `json:"depends_on,omitempty"`
+	// Weight 为任务在状态汇总 (/api/tree) 中的权重，数值越大越关键，0 按 1 处理
+	Weight int `json:"weight,omitempty"`
+	// PerfDedupPct 开启性能日志去重：响应耗时相对上次写入值的偏离不超过该百分比时不写入，0 表示每次都写入
+	PerfDedupPct int `json:"perf_dedup_pct,omitempty"`
+	// PerfKeepaliveEvery 为去重时最多连续跳过的样本数，到达后无论是否变化都写入一次，默认 10
+	PerfKeepaliveEvery int `json:"perf_keepalive_every,omitempty"`
+	// SecurityHeaders 为响应必须携带的安全头（如 "X-Content-Type-Options"），"baseline" 展开为内置的安全基线；
+	// 缺失时任务标记为黄色（不判定故障），缺失集合变化时记录事件并通知
+	SecurityHeaders []string `json:"security_headers,omitempty"`
+	// Timeout 为该任务单次请求的超时（秒），适合响应较慢但仍存活的后端；0 表示使用全局 request_timeout_sec
+	Timeout int `json:"timeout,omitempty"`
+	// Method 为检查使用的请求方法（GET/HEAD/POST/PUT），为空时沿用默认策略：先 HEAD，不支持时回退 GET
+	Method string `json:"method,omitempty"`
+	// Body 为 POST/PUT 请求携带的请求体，合法 JSON 以 application/json 发送，否则按纯文本发送
+	Body string `json:"body,omitempty"`
+	// Headers 为检查请求附加的请求头（如 Authorization、Host），覆盖默认的 User-Agent 等；
+	// 值在写入配置文件时加密，接口与页面中以 *** 展示
+	Headers map[string]string `json:"headers,omitempty"`
+	// ExpectedStatus 为判定成功的状态码集合（如 "200-299,401"），设置后不跟随跳转、按首个响应判定；
+	// 为空时沿用默认规则：跟随跳转，最终状态码在 200~399 之间即成功
+	ExpectedStatus string `json:"expected_status,omitempty"`
+	// MustContain 为响应体必须包含的文本（区分大小写），缺失即判定故障，用于发现返回 200 的错误页
+	MustContain string `json:"must_contain,omitempty"`
+	// MustNotContain 为响应体不得出现的文本（区分大小写，如 "Service Unavailable"），出现即判定故障
+	MustNotContain string `json:"must_not_contain,omitempty"`
+	// Type 为任务类型：http（默认，为空同 http）、tcp 或 ping；tcp 任务的 url 写作 host:port，只检查端口能否建立连接；
+	// ping 任务的 url 为主机名或 IP，发送 ICMP 回显请求（需 root 或 CAP_NET_RAW）
+	Type string `json:"type,omitempty"`
+	// PingCount 为 ping 任务每次检查发送的 ICMP 回显请求数（1~20），默认 3；部分丢包标黄，全部丢失判定故障
+	PingCount int `json:"ping_count,omitempty"`
+	// Paused 为 true 时任务暂停：保留配置、历史与最近一次结果，但不参与定时检查与告警，通过 /api/task/pause 切换
+	Paused bool `json:"paused,omitempty"`
+	// BasicAuthUser / BasicAuthPass 为 HTTP Basic 认证凭据，设置后每次检查请求都携带 Authorization 头；
+	// 密码在保存到 config.json 时加密，接口与页面中不回显
+	BasicAuthUser string `json:"basic_auth_user,omitempty"`
+	BasicAuthPass string `json:"basic_auth_pass,omitempty"`
 }
 
 type MonitorResult struct {
-	ID          int
-	TaskName    string
-	URL         string
-	StatusCode  int
-	Duration    string // 响应时间格式化字符串（如 "123ms"）
-	DurationInt int64  // 响应时间原始毫秒数，用于排序
-	Status      string // 状态描述（如 "正常"、"失败"）
-	StatusColor string // 前端颜色标识
-	IsSuccess   bool
-	LastUpdate  string   // 上次检查时间格式化字符串
-	HistoryDots []string // 历史状态点阵，用于图表显示
-	Starred     bool     // 传递给前端的标星状态
+	ID           int      `json:"id"`
+	TaskName     string   `json:"task_name"`
+	Tenant       string   `json:"tenant,omitempty"`
+	URL          string   `json:"url"`
+	StatusCode   int      `json:"status_code"`
+	Duration     string   `json:"duration"`              // 响应时间格式化字符串（按量级取单位，如 "45µs"、"123ms"、"1.2s"）
+	DurationInt  int64    `json:"duration_ms"`           // 响应时间原始毫秒数，用于排序
+	Status       string   `json:"status"`                // 状态描述（如 "正常"、"失败"）
+	StatusColor  string   `json:"status_color"`          // 前端颜色标识
+	FailReason   string   `json:"fail_reason,omitempty"` // 失败原因说明，成功时为空
+	RetryAfter   int      `json:"retry_after,omitempty"` // 服务端 Retry-After 要求推迟的秒数（仅 429/503），期间跳过该任务的定时检查
+	IsSuccess    bool     `json:"is_success"`
+	Retries      int      `json:"retries"`        // 本次检查实际执行的重试次数，持续偏高说明上游不稳定
+	Inverted     bool     `json:"inverted"`       // 是否为反向监控结果
+	Flapping     bool     `json:"flapping"`       // 任务处于抖动状态（状态频繁切换）
+	DNSFailure   bool     `json:"dns_failure"`    // 失败原因是 DNS 解析失败，告警判定使用单独的阈值
+	HasCert      bool     `json:"has_cert"`       // 是否获取到 HTTPS 证书信息
+	CertDaysLeft int      `json:"cert_days_left"` // 证书剩余有效天数，仅 HasCert 为 true 时有意义
+	CertExpiring bool     `json:"cert_expiring"`  // 证书剩余天数已低于该任务的预警阈值
+	LastUpdate   string   `json:"last_update"`    // 上次检查时间格式化字符串
+	HistoryDots  []string `json:"history_dots"`   // 历史状态点阵，用于图表显示
+	Starred      bool     `json:"starred"`        // 传递给前端的标星状态
+
+	// Proto 为本次检查实际使用的协议（如 "HTTP/1.1"、"HTTP/2.0"、"HTTP/3"），未拿到响应时为空
+	Proto string `json:"proto,omitempty"`
+	// SLATargetMS 为任务的响应时间 SLA 目标（毫秒），未设置时为 0
+	SLATargetMS int64 `json:"sla_target_ms,omitempty"`
+	// SLAMet 表示本次检查成功且耗时不超过 SLA 目标，仅 SLATargetMS > 0 时有意义
+	SLAMet bool `json:"sla_met"`
+	// FailHeaders 为开启 capture_fail_headers 时失败响应的响应头快照，仅随告警事件入库，不对外输出
+	FailHeaders []InspectHeader `json:"-"`
+	// BodyPreview 为开启 include_body_preview 时内容断言失败的响应体预览（已脱敏且有界），仅随告警输出
+	BodyPreview string `json:"-"`
+	// MissingHeaders 为任务要求但本次响应缺失的安全头
+	MissingHeaders []string `json:"missing_headers,omitempty"`
+	// TimeoutSec 为本次检查实际生效的请求超时（秒）
+	TimeoutSec int `json:"timeout_sec"`
+	// KeywordRule 为未通过的关键字规则：must_contain 或 must_not_contain，通过或未设置时为空
+	KeywordRule string `json:"keyword_rule,omitempty"`
+	// PacketLoss 为 ping 任务本次检查的丢包率（百分比）
+	PacketLoss int `json:"packet_loss,omitempty"`
+	// Unavailable 表示检查方式在当前环境不可用（如缺少 ICMP 权限），结果不计入成功或失败、不触发告警
+	Unavailable bool `json:"unavailable,omitempty"`
+	// Paused 表示任务已暂停，展示的是暂停前最近一次的检查结果
+	Paused bool `json:"paused,omitempty"`
+	// Maintenance 为当前对该任务生效的维护窗口名称，窗口内不发送告警与恢复通知
+	Maintenance string `json:"maintenance,omitempty"`
+	// Attempts 为本次检查的总尝试次数（首次请求 + 重试）
+	Attempts int `json:"attempts"`
+	// SucceededAttempt 为重试后最终成功的那次尝试序号（从 1 开始），首次即成功或最终失败时为 0
+	SucceededAttempt int `json:"succeeded_attempt,omitempty"`
+	// RetryReasons 依次记录被重试覆盖的各次失败原因，便于排查偶发故障
+	RetryReasons []string `json:"retry_reasons,omitempty"`
+}
+
+// SparkPoint 是迷你趋势图中的一个检查结果点。
+type SparkPoint struct {
+	Time string `json:"time"` // 检查时间（时:分:秒）
+	MS   int64  `json:"ms"`   // 响应耗时（毫秒）
+	OK   bool   `json:"ok"`   // 检查是否成功
+}
+
+// ReconcileResult 描述一次声明式任务同步产生的差异。
+type ReconcileResult struct {
+	Added     []MonitorTask   `json:"added"`
+	Updated   []MonitorTask   `json:"updated"`
+	Removed   []MonitorTask   `json:"removed"`
+	Unchanged int             `json:"unchanged"`
+	Skipped   []ReconcileSkip `json:"skipped,omitempty"` // 宽松同步（任务发现）中因校验失败被跳过的条目
+}
+
+// ReconcileSkip 是宽松同步中被跳过的一条期望任务及其原因。
+type ReconcileSkip struct {
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+// InspectHeader 是调试快照中的一个响应头。
+type InspectHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// InspectResult 是对任务执行一次性调试检查得到的响应快照，不写入日志也不影响告警状态。
+type InspectResult struct {
+	TaskID           int             `json:"task_id"`
+	URL              string          `json:"url"`
+	Method           string          `json:"method"`
+	StatusLine       string          `json:"status_line"` // 如 "HTTP/1.1 200 OK"
+	StatusCode       int             `json:"status_code"`
+	Duration         string          `json:"duration"`
+	Headers          []InspectHeader `json:"headers"`
+	HeadersTruncated bool            `json:"headers_truncated"` // 响应头数量超过上限，仅返回了部分
+	Error            string          `json:"error,omitempty"`
 }
 
 // TaskState 用于内部维护每个任务的动态状态（失败计数、上次告警时间、是否宕机）。
 type TaskState struct {
 	ConsecutiveFails int
+	TotalChecks      int // 本次运行以来该任务累计的检查次数，用于判断是否仍在预热期
 	LastAlertTime    time.Time
 	IsDown           bool
+	BudgetBreached   bool        // 错误率当前是否超标
+	LastBudgetAlert  time.Time   // 上次发送错误率告警的时间
+	HasLastOutcome   bool        // 是否已有上一次检查结果
+	LastSuccess      bool        // 上一次检查是否成功，用于识别状态切换
+	Transitions      []time.Time // 抖动窗口内的状态切换时间
+	Flapping         bool        // 是否处于抖动状态
+	LastFlapChange   time.Time   // 上次进入或退出抖动状态的时间
+	DeferUntil       time.Time   // 服务端 Retry-After 要求的推迟截止时间，之前的定时检查会跳过该任务
+	ConsecutiveSlow  int         // 连续“缓慢”的检查次数
+	DegradedAt       time.Time   // 发出响应缓慢预警的时间，宕机告警据此说明故障演变；零值表示未预警
+	BurnBreached     bool        // 多窗口错误率规则当前是否处于超标状态
+	LastBurnAlert    time.Time   // 上次发送多窗口错误率告警的时间
+	UpSince          time.Time   // 宕机后首次恢复正常的时间，持续满 MinRecoverSec 才确认恢复；零值表示未在观察期
+	DownSince        time.Time   // 本次故障确认宕机的时间，用于生成故障去重键；零值表示未宕机
+	Suppressed       bool        // 本次故障的首次告警因上游任务故障被抑制，恢复时同样不发通知
+	PerfStored       bool        // 性能日志去重：是否已有可比较的上次写入值（检查失败后清除）
+	PerfLastMS       int64       // 性能日志去重：上次写入的响应耗时（毫秒）
+	PerfSkipped      int         // 性能日志去重：自上次写入以来跳过的样本数
+	MissingHeaders   string      // 上次检查缺失的安全头（逗号分隔），用于只在缺失集合变化时记录事件
+	LastCertAlert    time.Time   // 上次发送证书即将过期预警的时间，证书续期后清零
+	CheckUnavailable bool        // 检查方式当前不可用（如缺少 ICMP 权限），已记录过说明事件
 }
 
 // EventLog 记录系统重要事件（如告警触发、恢复），用于历史追溯。
 type EventLog struct {
 	gorm.Model
-	TaskName   string
-	EventTime  string // 事件发生时间（格式化）
-	Type       string // 事件类型（如 "alert", "recover"）
-	Message    string
-	IsResolved bool // 标记告警是否已解除
+	TaskName    string
+	Tenant      string `gorm:"index"` // 所属项目/租户，为空表示默认项目
+	EventTime   string // 事件发生时间（格式化）
+	Type        string // 事件类型（如 "alert", "recover"）
+	Message     string
+	IsResolved  bool   // 标记告警是否已解除
+	Headers     string // 故障时刻的响应头快照（JSON 数组，已脱敏且有界），未开启采集或无响应时为空
+	DedupKey    string `gorm:"index"` // 故障去重键：同一次故障的宕机告警（含冷却后重复告警）与恢复事件相同，下一次故障重新生成
+	BodyPreview string // 内容断言失败时的响应体预览（已脱敏且有界），未开启 include_body_preview 时为空
+}
+
+// TaskStar 记录一个已标星的任务。标星属于看板展示偏好，单独存表，切换时不改写任务配置。
+type TaskStar struct {
+	TaskID    int `gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time
+}
+
+// SchemaMigration 记录已应用到数据库的结构版本，用于启动时的版本检查。
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// ConfigRecord 在数据库中保存一份完整的配置 JSON（敏感字段已加密），供多实例共享同一配置。
+type ConfigRecord struct {
+	Name      string `gorm:"primaryKey"` // 配置名称，对应环境（MONITOR_PROFILE），默认 "default"
+	Data      string // 序列化后的配置内容
+	Version   int64  `gorm:"not null;default:0"` // 每次保存加一，用于多实例并发保存时的冲突检测
+	UpdatedAt time.Time
 }
 
 // PerformanceLog 记录每次检查的响应时间，用于性能趋势分析。
@@ -91,8 +439,12 @@ type PerformanceLog struct {
 	gorm.Model
 	TaskID       int
 	TaskName     string
-	ResponseTime int64  // 响应时间（毫秒）
+	Tenant       string `gorm:"index"` // 所属项目/租户，为空表示默认项目
+	ResponseTime int64  // 响应时间（毫秒），失败的检查为实际耗时（超时即为超时时长）
 	CheckTime    string // 检查时间（格式化）
+	StatusCode   int    // HTTP 状态码，未拿到响应或非 HTTP 任务为 0
+	// Failed 表示本次检查失败。以“失败”而非“成功”建列，旧库中的历史记录迁移后默认即为成功样本
+	Failed bool
 }
 
 // StabilityAnalysis 表示稳定性分析模块的统一输出结构。
@@ -114,6 +466,39 @@ type RiskAssessment struct {
 	Reason string `json:"reason"`
 }
 
+// AlertTemplateData 是告警邮件模板（alert_template）可引用的字段，如 {{.TaskName}}、{{.FailCount}}。
+type AlertTemplateData struct {
+	TaskName   string `json:"task_name"`
+	Tenant     string `json:"tenant"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+	FailReason string `json:"fail_reason"`
+	FailCount  int    `json:"fail_count"`
+	Duration   string `json:"duration"`
+	Time       string `json:"time"`
+	Message    string `json:"message"` // 未使用模板时的默认告警正文
+	// BodyPreview 为内容断言失败时的响应体预览，开启 include_body_preview 时才有值
+	BodyPreview string `json:"body_preview"`
+}
+
+// WebhookPayload 是告警/恢复等事件推送给 Webhook 的 JSON 结构，也是 Webhook 请求体模板可引用的字段。
+type WebhookPayload struct {
+	Event      string `json:"event"` // "alert" 或 "recover"
+	Subject    string `json:"subject,omitempty"`
+	TaskID     int    `json:"task_id"`
+	TaskName   string `json:"task_name"`
+	URL        string `json:"url"`
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+	FailCount  int    `json:"fail_count"`
+	Message    string `json:"message"`
+	Time       string `json:"time"`
+	DedupKey   string `json:"dedup_key,omitempty"` // 故障去重键，同一次故障的告警与恢复相同
+	// BodyPreview 为内容断言失败时的响应体预览（开启 include_body_preview 时）
+	BodyPreview string `json:"body_preview,omitempty"`
+}
+
 // StabilitySnapshot 表示用于卡片与详情面板展示的聚合监控快照。
 type StabilitySnapshot struct {
 	TotalTasks       int `json:"total_tasks"`
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// groupedAlert 是分组窗口内等待合并发送的一条告警邮件。
+type groupedAlert struct {
+	To      string // 收件人，为空表示全局收件人
+	Subject string
+	Body    string
+}
+
+// alertGroup 在窗口期内收集告警邮件，窗口结束后合并为一封发送，
+// 避免共享依赖故障导致大量任务同时宕机时告警邮件刷屏。
+type alertGroup struct {
+	mu      sync.Mutex
+	pending []groupedAlert
+	timer   *time.Timer
+}
+
+// queueAlertMail 发送告警邮件（to 为空时发给全局收件人）：未开启分组时立即投递，否则加入当前分组窗口。
+func (s *Service) queueAlertMail(to, subject, body string) {
+	if s.notificationsMuted(subject) {
+		return
+	}
+	window := time.Duration(s.cfg.Get().GroupAlertWindowSec) * time.Second
+	if window <= 0 {
+		go s.deliverMail(to, subject, body)
+		return
+	}
+
+	g := &s.alertGroup
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pending = append(g.pending, groupedAlert{To: to, Subject: subject, Body: body})
+	if g.timer == nil {
+		g.timer = time.AfterFunc(window, s.flushAlertGroup)
+	}
+}
+
+// flushAlertGroup 发送窗口内收集到的告警：按收件人分组，组内只有一条时保持原样，多条时合并为一封汇总邮件。
+func (s *Service) flushAlertGroup() {
+	g := &s.alertGroup
+	g.mu.Lock()
+	alerts := g.pending
+	g.pending = nil
+	g.timer = nil
+	g.mu.Unlock()
+
+	var order []string
+	byTo := map[string][]groupedAlert{}
+	for _, a := range alerts {
+		if _, ok := byTo[a.To]; !ok {
+			order = append(order, a.To)
+		}
+		byTo[a.To] = append(byTo[a.To], a)
+	}
+	for _, to := range order {
+		s.deliverAlertGroup(to, byTo[to])
+	}
+}
+
+// deliverAlertGroup 发送同一收件人的一组告警。
+func (s *Service) deliverAlertGroup(to string, alerts []groupedAlert) {
+	if len(alerts) == 1 {
+		s.deliverMail(to, alerts[0].Subject, alerts[0].Body)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "以下 %d 个服务在同一时段内确认故障，可能存在共同依赖问题：\n\n", len(alerts))
+	for i, a := range alerts {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, a.Body)
+	}
+	s.deliverMail(to, fmt.Sprintf("🔥 [报警] %d 个服务同时宕机", len(alerts)), b.String())
+}
@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// alertLogEntry 是告警日志中的一行。字段名与取值保持稳定，供 Filebeat/Fluentd 等采集后直接入 SIEM。
+type alertLogEntry struct {
+	Timestamp  string `json:"timestamp"` // RFC3339 毫秒精度
+	TaskID     int    `json:"task_id"`
+	TaskName   string `json:"task_name"`
+	Tenant     string `json:"tenant,omitempty"`
+	URL        string `json:"url"`
+	Type       string `json:"type"`     // alert / recover
+	Severity   string `json:"severity"` // critical / info
+	DedupKey   string `json:"dedup_key"`
+	StatusCode int    `json:"status_code"`
+	FailCount  int    `json:"fail_count"`
+	Suppressed bool   `json:"suppressed"` // 通知是否因上游故障被抑制
+	Message    string `json:"message"`
+}
+
+// alertLog 以追加方式写入 JSON Lines 告警日志。文件按需打开，路径变化或收到 Reopen 时重新打开，
+// 配合 logrotate 的 move + SIGHUP 即可完成轮转。
+type alertLog struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+}
+
+// write 把一条记录追加到 path 指向的文件；path 为空时关闭已打开的文件并忽略记录。
+func (l *alertLog) write(path string, entry alertLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if path != l.path {
+		l.closeLocked()
+		l.path = path
+	}
+	if path == "" {
+		return
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+	if l.f == nil {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			log.Printf("⚠️ 打开告警日志 %s 失败: %v", path, err)
+			return
+		}
+		l.f = f
+	}
+	if _, err := l.f.Write(line); err != nil {
+		log.Printf("⚠️ 写入告警日志 %s 失败: %v", path, err)
+		// 文件可能已被删除或所在磁盘异常，下次写入时重新打开
+		l.closeLocked()
+	}
+}
+
+// reopen 关闭当前文件，下一次写入时按路径重新打开（日志轮转后调用）。
+func (l *alertLog) reopen() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closeLocked()
+}
+
+func (l *alertLog) closeLocked() {
+	if l.f != nil {
+		_ = l.f.Close()
+		l.f = nil
+	}
+}
+
+// ReopenAlertLog 重新打开告警日志文件，供收到 SIGHUP 时调用。
+func (s *Service) ReopenAlertLog() {
+	s.alertLog.reopen()
+	if path := s.cfg.Get().AlertLogPath; path != "" {
+		log.Printf("🔄 告警日志将重新打开: %s", path)
+	}
+}
+
+// writeAlertLog 把一次宕机告警或恢复写入告警日志，未配置 alert_log_path 时不做任何事。
+func (s *Service) writeAlertLog(event string, res model.MonitorResult, failCount int, dedupKey string, suppressed bool, msg string) {
+	c := s.cfg.Get()
+	severity := "critical"
+	if event == "recover" {
+		severity = "info"
+	}
+	url := res.URL
+	if c.MaskSecrets {
+		url = config.MaskURL(url)
+	}
+	s.alertLog.write(c.AlertLogPath, alertLogEntry{
+		Timestamp:  time.Now().Format("2006-01-02T15:04:05.000Z07:00"),
+		TaskID:     res.ID,
+		TaskName:   res.TaskName,
+		Tenant:     res.Tenant,
+		URL:        url,
+		Type:       event,
+		Severity:   severity,
+		DedupKey:   dedupKey,
+		StatusCode: res.StatusCode,
+		FailCount:  failCount,
+		Suppressed: suppressed,
+		Message:    msg,
+	})
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// AlertChannelResult 为一次告警通道测试中单个通道的投递结果。
+type AlertChannelResult struct {
+	Channel string `json:"channel"` // email / webhook
+	Target  string `json:"target"`  // 实际使用的收件人或 Webhook 地址
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TestTaskAlert 按任务的实际告警路由（任务级收件人/Webhook 覆盖全局配置）同步发送一条测试通知，
+// 返回每个通道的结果。测试通知不经过告警分组与重发队列，不受通知总开关影响，也不写入事件日志。
+func (s *Service) TestTaskAlert(id int) ([]AlertChannelResult, error) {
+	task, ok := s.cfg.GetTask(id)
+	if !ok {
+		return nil, fmt.Errorf("任务不存在: %d", id)
+	}
+	// 模板地址按当前变量展开后再展示，展开失败时保留原始模板；开启脱敏时隐去地址中的凭据，与告警保持一致
+	cfg := s.cfg.Get()
+	target, err := config.ExpandURL(task.URL, cfg.Vars, task.Vars)
+	if err != nil {
+		target = task.URL
+	}
+	if cfg.MaskSecrets {
+		target = config.MaskURL(target)
+	}
+	subject := "🧪 [测试] " + task.Name + " 告警通道测试"
+	body := fmt.Sprintf("这是一条测试通知，用于确认任务 %s 的告警路由正常。\n地址: %s\n时间: %s",
+		task.Name, target, time.Now().Format("2006-01-02 15:04:05"))
+	var results []AlertChannelResult
+	base := model.WebhookPayload{Event: "test", TaskID: task.ID, TaskName: task.Name, URL: target}
+	for _, n := range s.taskNotifiers(task, base, mailDirect) {
+		results = append(results, channelResult(n.Channel(), n.Target(), n.Notify(subject, body)))
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("任务 %s 没有可用的告警通道（邮件与 Webhook 均未启用）", task.Name)
+	}
+	return results, nil
+}
+
+func channelResult(channel, target string, err error) AlertChannelResult {
+	r := AlertChannelResult{Channel: channel, Target: target, OK: err == nil}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
@@ -0,0 +1,189 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"monitor/internal/model"
+)
+
+// wantsJSON 判断任务是否需要 JSON 校验；手工编辑配置时可能只填了必需键。
+func wantsJSON(task model.MonitorTask) bool {
+	return task.ValidateJSON || len(task.RequiredKeys) > 0
+}
+
+// needsBody 判断任务的内容断言是否需要读取响应体。
+func needsBody(task model.MonitorTask) bool {
+	return wantsJSON(task) || task.BodyRegex != "" || task.MustContain != "" || task.MustNotContain != ""
+}
+
+// bodyRegexCache 缓存已编译的响应体正则，按表达式文本索引，避免每次检查重复编译。
+var bodyRegexCache sync.Map // map[string]*regexp.Regexp
+
+// compileBodyRegex 返回表达式对应的已编译正则，首次使用时编译并缓存。
+func compileBodyRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := bodyRegexCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	bodyRegexCache.Store(expr, re)
+	return re, nil
+}
+
+// assertResponse 对状态码正常的响应执行任务配置的内容断言，返回首个失败原因，全部通过时返回空串。
+// target 为展开模板后的实际请求地址。
+func assertResponse(task model.MonitorTask, target string, resp probeResponse) string {
+	if task.RequireHTTPSRedirect {
+		if reason := checkHTTPSRedirect(target, resp); reason != "" {
+			return reason
+		}
+	}
+	if task.ExpectContentType != "" {
+		if reason := checkContentType(task, resp); reason != "" {
+			return reason
+		}
+	}
+	if wantsJSON(task) {
+		if reason := checkJSONBody(task, resp); reason != "" {
+			return reason
+		}
+	}
+	if task.BodyRegex != "" {
+		if reason := checkBodyRegex(task, resp); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// checkBodyRegex 校验响应体能匹配任务配置的正则；响应体超过上限时只在已读取的部分中匹配。
+func checkBodyRegex(task model.MonitorTask, resp probeResponse) string {
+	re, err := compileBodyRegex(task.BodyRegex)
+	if err != nil {
+		// 正常情况下保存时已校验，这里只可能来自手工编辑的配置文件
+		return "响应体正则不合法: " + err.Error()
+	}
+	if re.Match(resp.Body) {
+		return ""
+	}
+	if resp.BodyTruncated {
+		return fmt.Sprintf("响应体前 %dKB 未匹配正则 %s", maxBodyBytes/1024, task.BodyRegex)
+	}
+	return "响应体未匹配正则 " + task.BodyRegex
+}
+
+// 关键字规则名，记录在结果的 keyword_rule 中。
+const (
+	keywordMustContain    = "must_contain"
+	keywordMustNotContain = "must_not_contain"
+)
+
+// checkKeywords 检查响应体的关键字规则，返回未通过的规则名与失败原因，全部通过时返回空串。
+// 响应体最多读取 maxBodyBytes，被截断时必含文本可能位于未读取的部分，原因中会注明。
+func checkKeywords(task model.MonitorTask, resp probeResponse) (rule, reason string) {
+	if task.MustNotContain != "" && bytes.Contains(resp.Body, []byte(task.MustNotContain)) {
+		return keywordMustNotContain, fmt.Sprintf("响应体包含禁止出现的文本 %q", task.MustNotContain)
+	}
+	if task.MustContain != "" && !bytes.Contains(resp.Body, []byte(task.MustContain)) {
+		if resp.BodyTruncated {
+			return keywordMustContain, fmt.Sprintf("响应体前 %dKB 未包含必需文本 %q", maxBodyBytes/1024, task.MustContain)
+		}
+		return keywordMustContain, fmt.Sprintf("响应体未包含必需文本 %q", task.MustContain)
+	}
+	return "", ""
+}
+
+// checkHTTPSRedirect 校验 http 地址以 3xx 跳转到 https，Location 为相对地址时按实际请求地址 target 解析。
+func checkHTTPSRedirect(target string, resp probeResponse) string {
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+		return fmt.Sprintf("未跳转到 HTTPS（状态码 %d）", resp.StatusCode)
+	}
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return fmt.Sprintf("跳转响应（状态码 %d）缺少 Location", resp.StatusCode)
+	}
+	base, err := url.Parse(target)
+	if err != nil {
+		return "URL 格式不合法"
+	}
+	dest, err := base.Parse(loc)
+	if err != nil {
+		return "Location 不合法: " + loc
+	}
+	if dest.Scheme != "https" {
+		return "跳转目标不是 HTTPS: " + dest.String()
+	}
+	return ""
+}
+
+// checkContentType 校验响应的媒体类型以任务期望的类型为前缀，用于发现被误路由到错误页（如 200 的 text/html）。
+func checkContentType(task model.MonitorTask, resp probeResponse) string {
+	ct := resp.Header.Get("Content-Type")
+	if ct == "" {
+		return "响应缺少 Content-Type，期望 " + task.ExpectContentType
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.SplitN(ct, ";", 2)[0]))
+	}
+	// 保存时已规范化，这里再处理一次以兼容手工编辑的配置文件
+	want := strings.ToLower(strings.TrimSpace(strings.SplitN(task.ExpectContentType, ";", 2)[0]))
+	if !strings.HasPrefix(mediaType, want) {
+		return fmt.Sprintf("Content-Type 不符: 期望 %s，实际 %s", task.ExpectContentType, mediaType)
+	}
+	return ""
+}
+
+// mayBeJSON 判断 Content-Type 是否声明为 JSON 类型（含 +json 后缀）。text/plain 等其他类型不纳入校验，
+// 避免纯文本健康检查页（如 "OK"）在开启 validate_json 后被误判为失败。
+func mayBeJSON(ct string) bool {
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "text/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// checkJSONBody 校验响应体是合法 JSON，并包含要求的顶层键。
+// 响应声明了明确非 JSON 的 Content-Type（如 text/html）时跳过校验，由内容类型断言负责判定；未声明时按 JSON 处理。
+func checkJSONBody(task model.MonitorTask, resp probeResponse) string {
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !mayBeJSON(ct) {
+		return ""
+	}
+	if resp.BodyTruncated {
+		return fmt.Sprintf("响应体超过 %dKB 上限，无法完整校验 JSON", maxBodyBytes/1024)
+	}
+	if len(task.RequiredKeys) == 0 {
+		if !json.Valid(resp.Body) {
+			return "响应体不是合法的 JSON"
+		}
+		return ""
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(resp.Body, &obj); err != nil {
+		if json.Valid(resp.Body) {
+			return "JSON 顶层不是对象，无法检查必需字段"
+		}
+		return "响应体不是合法的 JSON"
+	}
+	var missing []string
+	for _, k := range task.RequiredKeys {
+		if _, ok := obj[k]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	if len(missing) > 0 {
+		return "JSON 缺少必需字段: " + strings.Join(missing, ", ")
+	}
+	return ""
+}
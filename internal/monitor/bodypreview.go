@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+var (
+	// secretQuotedValue 匹配形如 "token": "xxx" / password="xxx" 的带引号敏感字段值
+	secretQuotedValue = regexp.MustCompile(`(?i)("?[a-z0-9_.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|authorization|credential|session|cookie)[a-z0-9_.-]*"?\s*[:=]\s*)"[^"]*"`)
+	// secretBareValue 匹配形如 token=xxx / secret: xxx 的不带引号敏感字段值
+	secretBareValue = regexp.MustCompile(`(?i)([a-z0-9_.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|authorization|credential|session|cookie)[a-z0-9_.-]*\s*[:=]\s*)([^\s"',&;}<]+)`)
+	// bearerToken 匹配 Authorization 头风格的 Bearer 令牌
+	bearerToken = regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9._~+/=-]+`)
+	// jwtToken 匹配独立出现的 JWT
+	jwtToken = regexp.MustCompile(`\beyJ[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+\.[a-zA-Z0-9_-]+`)
+)
+
+// redactSecrets 将文本中疑似密钥、令牌、口令的值替换为 ***。
+func redactSecrets(s string) string {
+	s = secretQuotedValue.ReplaceAllString(s, `${1}"***"`)
+	s = secretBareValue.ReplaceAllString(s, `${1}***`)
+	s = bearerToken.ReplaceAllString(s, "Bearer ***")
+	return jwtToken.ReplaceAllString(s, "***")
+}
+
+// bodyPreview 截取响应体开头至多 limit 字节作为预览：去除非法 UTF-8 与控制字符、脱敏敏感字段，
+// 截断时不拆分多字节字符并以 … 结尾。
+func bodyPreview(body []byte, limit int) string {
+	if len(body) == 0 || limit <= 0 {
+		return ""
+	}
+	// 多取一些再脱敏，避免截断点恰好落在敏感字段名与值之间导致漏判
+	window := body
+	if len(window) > limit*2 {
+		window = window[:limit*2]
+	}
+	s := strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, strings.ToValidUTF8(string(window), ""))
+	s = redactSecrets(s)
+
+	truncated := len(body) > len(window) || len(s) > limit
+	if len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut]
+	}
+	s = strings.TrimSpace(s)
+	if truncated {
+		s += "…"
+	}
+	return s
+}
+
+// captureBodyPreview 在开启 include_body_preview 时为内容断言失败的结果保存响应体预览。
+func (s *Service) captureBodyPreview(res *model.MonitorResult, body []byte) {
+	cfg := s.cfg.Get()
+	if !cfg.IncludeBodyPreview {
+		return
+	}
+	preview := bodyPreview(body, cfg.BodyPreviewBytes)
+	if cfg.MaskSecrets {
+		preview = config.MaskURLIn(preview, res.URL)
+	}
+	res.BodyPreview = preview
+}
+
+// withBodyPreview 在告警正文末尾附上响应体预览，无预览时原样返回。
+func withBodyPreview(body, preview string) string {
+	if preview == "" {
+		return body
+	}
+	return body + "\n\n响应内容预览:\n" + preview
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// burnRateEvalInterval 是多窗口错误率规则的评估周期。
+const burnRateEvalInterval = 30 * time.Second
+
+// burnRateAlert 是一次评估中需要发出的多窗口错误率告警。
+type burnRateAlert struct {
+	res                    model.MonitorResult
+	rule                   model.BurnRateRule
+	shortFails, shortTotal int
+	longFails, longTotal   int
+}
+
+// runBurnRateEvaluator 周期性评估配置了 burn_rate 的任务，直到 ctx 结束。
+func (s *Service) runBurnRateEvaluator(ctx context.Context) {
+	ticker := time.NewTicker(burnRateEvalInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.evaluateBurnRates(time.Now())
+		}
+	}
+}
+
+// evaluateBurnRates 基于按分钟统计的检查结果（与错误率规则共用）计算短、长两个窗口的失败率，
+// 两者同时超标才告警；持续超标时按告警冷却时间重复提醒，任一窗口回落后复位。
+func (s *Service) evaluateBurnRates(now time.Time) {
+	c := s.cfg.Get()
+	_, cooldown := alertRules(c)
+	var alerts []burnRateAlert
+
+	s.mu.Lock()
+	for _, t := range c.Tasks {
+		if t.BurnRate == nil || t.Archived {
+			continue
+		}
+		rule := *t.BurnRate
+		if err := config.NormalizeBurnRate(&rule); err != nil {
+			continue
+		}
+		ol, st := s.outcomes[t.ID], s.states[t.ID]
+		if ol == nil || st == nil {
+			continue
+		}
+		sf, stot := ol.count(now, time.Duration(rule.ShortWindowMinutes)*time.Minute)
+		lf, ltot := ol.count(now, time.Duration(rule.LongWindowMinutes)*time.Minute)
+		breached := stot >= rule.MinSamples && ltot >= rule.MinSamples &&
+			float64(sf)*100 > rule.ShortMaxPct*float64(stot) &&
+			float64(lf)*100 > rule.LongMaxPct*float64(ltot)
+		if !breached {
+			st.BurnBreached = false
+			continue
+		}
+		if st.BurnBreached && now.Sub(st.LastBurnAlert) <= cooldown {
+			continue
+		}
+		st.BurnBreached = true
+		st.LastBurnAlert = now
+		alerts = append(alerts, burnRateAlert{
+			res:        s.latestResultLocked(t),
+			rule:       rule,
+			shortFails: sf, shortTotal: stot,
+			longFails: lf, longTotal: ltot,
+		})
+	}
+	s.mu.Unlock()
+
+	for _, a := range alerts {
+		s.notifyBurnRate(a)
+	}
+}
+
+// latestResultLocked 返回任务最近一次的检查结果，尚无结果时按任务配置构造，调用前需持有 s.mu。
+func (s *Service) latestResultLocked(t model.MonitorTask) model.MonitorResult {
+	for _, r := range s.results {
+		if r.ID == t.ID {
+			return r
+		}
+	}
+	return model.MonitorResult{ID: t.ID, TaskName: t.Name, Tenant: t.Tenant, URL: t.URL}
+}
+
+// notifyBurnRate 记录多窗口错误率超标事件并发送通知。
+func (s *Service) notifyBurnRate(a burnRateAlert) {
+	msg := fmt.Sprintf("服务 [%s] 短窗口 %d 分钟失败率 %.1f%%（%d/%d，阈值 %.1f%%），长窗口 %d 分钟失败率 %.1f%%（%d/%d，阈值 %.1f%%），均已超标",
+		a.res.TaskName,
+		a.rule.ShortWindowMinutes, float64(a.shortFails)*100/float64(a.shortTotal), a.shortFails, a.shortTotal, a.rule.ShortMaxPct,
+		a.rule.LongWindowMinutes, float64(a.longFails)*100/float64(a.longTotal), a.longFails, a.longTotal, a.rule.LongMaxPct)
+	s.repo.CreateEvent(&model.EventLog{
+		TaskName:  a.res.TaskName,
+		Tenant:    a.res.Tenant,
+		EventTime: time.Now().Format("2006-01-02 15:04:05"),
+		Type:      "🔥 多窗口错误率超标",
+		Message:   msg,
+	})
+	s.notifyTask(a.res.ID, newWebhookPayload("burn_rate", a.res, a.shortFails, msg), mailGrouped, "🔥 [报警] "+a.res.TaskName+" 多窗口错误率超标", msg)
+}
@@ -0,0 +1,64 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// certAlertDays 返回任务生效的证书预警天数：任务级设置优先，未设置时沿用全局值；0 表示不预警。
+func certAlertDays(task model.MonitorTask, globalDays int) int {
+	if task.CertExpiryAlertDays != nil {
+		return *task.CertExpiryAlertDays
+	}
+	return globalDays
+}
+
+// applyCertInfo 从 TLS 握手结果中提取叶子证书的剩余天数，并按任务阈值标记是否即将过期。
+func (s *Service) applyCertInfo(task model.MonitorTask, resp probeResponse, res *model.MonitorResult) {
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return
+	}
+	left := time.Until(resp.TLS.PeerCertificates[0].NotAfter)
+	res.HasCert = true
+	res.CertDaysLeft = int(math.Floor(left.Hours() / 24))
+	if days := certAlertDays(task, s.cfg.Get().CertWarnDays); days > 0 {
+		res.CertExpiring = res.CertDaysLeft < days
+	}
+}
+
+// evaluateCertExpiryLocked 判断是否需要发送证书即将过期预警：低于阈值时首次立即预警，
+// 之后与宕机告警一样按 alert_cooldown 冷却；证书续期（不再低于阈值）后清除记录。调用前需持有 s.mu。
+func (s *Service) evaluateCertExpiryLocked(res model.MonitorResult, st *model.TaskState, cooldown time.Duration) bool {
+	if !res.HasCert {
+		// 检查失败时拿不到证书信息，沿用上次的状态
+		return false
+	}
+	if !res.CertExpiring {
+		st.LastCertAlert = time.Time{}
+		return false
+	}
+	if !st.LastCertAlert.IsZero() && time.Since(st.LastCertAlert) <= cooldown {
+		return false
+	}
+	st.LastCertAlert = time.Now()
+	return true
+}
+
+// notifyCertExpiry 记录“🔒 证书即将过期”事件，并发送邮件与 Webhook（event 为 cert_expiring）。
+func (s *Service) notifyCertExpiry(res model.MonitorResult) {
+	msg := fmt.Sprintf("服务 [%s] 的 HTTPS 证书将在 %d 天后过期，请及时续期。", res.TaskName, res.CertDaysLeft)
+	if res.CertDaysLeft < 0 {
+		msg = fmt.Sprintf("服务 [%s] 的 HTTPS 证书已过期 %d 天！", res.TaskName, -res.CertDaysLeft)
+	}
+	s.repo.CreateEvent(&model.EventLog{
+		TaskName:  res.TaskName,
+		Tenant:    res.Tenant,
+		EventTime: time.Now().Format("2006-01-02 15:04:05"),
+		Type:      "🔒 证书即将过期",
+		Message:   msg,
+	})
+	s.notifyTask(res.ID, newWebhookPayload("cert_expiring", res, 0, msg), mailGrouped, fmt.Sprintf("🔒 [预警] %s 证书剩余 %d 天", res.TaskName, res.CertDaysLeft), msg)
+}
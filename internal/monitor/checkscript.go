@@ -0,0 +1,127 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+const (
+	// checkScriptEnv 为启用检查回调脚本的显式开关，配置文件中设置了脚本但未设置该变量时脚本不会执行
+	checkScriptEnv = "MONITOR_ALLOW_CHECK_SCRIPT"
+	// maxConcurrentScripts 为同时运行的回调脚本上限，名额用尽时本次回调直接丢弃，避免慢脚本拖垮进程
+	maxConcurrentScripts = 4
+	// maxScriptOutput 为记录到日志的脚本输出上限（字节）
+	maxScriptOutput = 2048
+)
+
+// checkScriptAllowed 报告是否通过环境变量显式允许执行检查回调脚本。
+func checkScriptAllowed() bool {
+	return os.Getenv(checkScriptEnv) == "1"
+}
+
+// logCheckScriptStatus 在启动时提示回调脚本的启用状态，便于确认配置是否生效。
+func (s *Service) logCheckScriptStatus() {
+	script := s.cfg.Get().OnCheckScript
+	if script == "" {
+		return
+	}
+	if !checkScriptAllowed() {
+		log.Printf("⚠️ 已配置检查回调脚本 %s，但未设置 %s=1，脚本不会执行", script, checkScriptEnv)
+		return
+	}
+	log.Printf("⚠️ 检查回调脚本已启用: %s（以监控进程的权限运行）", script)
+}
+
+// runCheckScript 异步调用配置的回调脚本，将检查结果以 JSON 写入其标准输入；
+// 退出码与输出写入日志。未启用、未显式允许或并发名额用尽时直接返回。
+func (s *Service) runCheckScript(res model.MonitorResult) {
+	c := s.cfg.Get()
+	if c.OnCheckScript == "" || !checkScriptAllowed() {
+		return
+	}
+	if c.MaskSecrets {
+		res.FailReason = config.MaskURLIn(res.FailReason, res.URL)
+		res.URL = config.MaskURL(res.URL)
+	}
+	payload, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	select {
+	case s.scriptSem <- struct{}{}:
+	default:
+		log.Printf("⚠️ 检查回调脚本并发已达上限 %d，跳过任务 %s 的本次回调", maxConcurrentScripts, res.TaskName)
+		return
+	}
+	timeout := time.Duration(c.OnCheckScriptTimeoutSec) * time.Second
+	go func() {
+		defer func() { <-s.scriptSem }()
+		execCheckScript(c.OnCheckScript, timeout, res.TaskName, payload)
+	}()
+}
+
+// execCheckScript 直接执行脚本（不经过 shell），超时后结束进程。
+func execCheckScript(script string, timeout time.Duration, taskName string, payload []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, script)
+	cmd.Stdin = bytes.NewReader(payload)
+	out := &limitedBuffer{max: maxScriptOutput}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		log.Printf("⚠️ 检查回调脚本超时（%s），任务 %s，输出: %s", timeout, taskName, out.String())
+	case err != nil:
+		code := -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		}
+		log.Printf("⚠️ 检查回调脚本执行失败（退出码 %d），任务 %s: %v，输出: %s", code, taskName, err, out.String())
+	case out.Len() > 0:
+		log.Printf("📜 检查回调脚本完成（退出码 0），任务 %s，输出: %s", taskName, out.String())
+	}
+}
+
+// limitedBuffer 只保留前 max 字节的输出，超出部分丢弃，防止脚本大量输出占满内存与日志。
+type limitedBuffer struct {
+	bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Buffer.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+			b.truncated = true
+		} else {
+			b.Buffer.Write(p)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	s := string(bytes.TrimSpace(b.Buffer.Bytes()))
+	if b.truncated {
+		s += "…（已截断）"
+	}
+	return s
+}
@@ -0,0 +1,155 @@
+package monitor
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// taskClient 缓存按任务定制的 HTTP 客户端，key 记录其生成依据，依据变化时重建。
+type taskClient struct {
+	key    string
+	client *http.Client
+}
+
+// needsCustomClient 判断任务是否需要独立的传输层配置。
+func needsCustomClient(task model.MonitorTask) bool {
+	return task.SourceIP != "" || task.ClientCertPath != "" || task.RequireHTTPSRedirect || task.MaxRedirects > 0 || task.UseHTTP3 || task.ExpectedStatus != ""
+}
+
+// noFollowRedirects 判断任务是否需要直接检查首个响应而不跟随跳转：
+// 校验 https 跳转，或设置了期望状态码（如把遗留接口的 302 视为故障）。
+func noFollowRedirects(task model.MonitorTask) bool {
+	return task.RequireHTTPSRedirect || task.ExpectedStatus != ""
+}
+
+// certFingerprint 以证书/私钥的路径与修改时间标识客户端证书，文件轮换后客户端随之重建。
+func certFingerprint(task model.MonitorTask) string {
+	if task.ClientCertPath == "" {
+		return ""
+	}
+	fp := task.ClientCertPath + "|" + task.ClientKeyPath
+	for _, p := range []string{task.ClientCertPath, task.ClientKeyPath} {
+		if fi, err := os.Stat(p); err == nil {
+			fp += "|" + fi.ModTime().Format(time.RFC3339Nano)
+		}
+	}
+	return fp
+}
+
+// sharedClient 返回当前的共享 HTTP 客户端。
+func (s *Service) sharedClient() *http.Client {
+	return s.client.Load()
+}
+
+// clientFor 返回任务应使用的 HTTP 客户端：无定制需求时复用共享客户端，
+// 否则按任务缓存专用客户端，避免每次检查都重建连接池。
+func (s *Service) clientFor(task model.MonitorTask) (*http.Client, error) {
+	if !needsCustomClient(task) {
+		return s.sharedClient(), nil
+	}
+
+	c := s.cfg.Get()
+	maxRedirects := c.MaxRedirects
+	if task.MaxRedirects > 0 {
+		maxRedirects = task.MaxRedirects
+	}
+	key := fmt.Sprintf("%d|%s|%s|%t|%d|%t", c.ConnectTimeoutSec, task.SourceIP, certFingerprint(task), noFollowRedirects(task), maxRedirects, task.UseHTTP3)
+
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	if tc, ok := s.taskClients[task.ID]; ok {
+		if tc.key == key {
+			return tc.client, nil
+		}
+		tc.client.CloseIdleConnections()
+	}
+	client, err := buildTaskClient(c.ConnectTimeoutSec, maxRedirects, task, s.dns)
+	if err != nil {
+		return nil, err
+	}
+	s.taskClients[task.ID] = &taskClient{key: key, client: client}
+	return client, nil
+}
+
+// dropTaskClient 释放任务的专用客户端。
+func (s *Service) dropTaskClient(taskID int) {
+	s.clientMu.Lock()
+	defer s.clientMu.Unlock()
+	if tc, ok := s.taskClients[taskID]; ok {
+		tc.client.CloseIdleConnections()
+		delete(s.taskClients, taskID)
+	}
+}
+
+// buildTaskClient 在共享客户端参数的基础上叠加任务级配置（出口源地址、客户端证书、跳转策略、HTTP/3）。
+func buildTaskClient(connectTimeoutSec, maxRedirects int, task model.MonitorTask, dns *dnsCache) (*http.Client, error) {
+	client := buildHTTPClient(connectTimeoutSec, maxRedirects, dns)
+	transport := client.Transport.(*http.Transport)
+
+	if task.SourceIP != "" {
+		ip := net.ParseIP(task.SourceIP)
+		if ip == nil {
+			return nil, fmt.Errorf("源地址不合法: %s", task.SourceIP)
+		}
+		dialer := &net.Dialer{
+			Timeout:   dialTimeout(connectTimeoutSec),
+			KeepAlive: 30 * time.Second,
+			LocalAddr: &net.TCPAddr{IP: ip},
+		}
+		transport.DialContext = dns.dialContext(dialer)
+	}
+	if task.ClientCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(task.ClientCertPath, task.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %v", err)
+		}
+		transport.TLSClientConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+	if noFollowRedirects(task) {
+		// 校验跳转本身（或按期望状态码判定首个响应），不跟随
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	if task.UseHTTP3 {
+		// 改走 QUIC 传输层，不回退到 TCP，以便确认 h3 路径本身可用
+		if newHTTP3Transport == nil {
+			return nil, errHTTP3Unavailable
+		}
+		client.Transport = newHTTP3Transport(transport.TLSClientConfig)
+	}
+	return client, nil
+}
+
+// describeProbeError 将探测错误转换为便于排查的失败原因。
+func describeProbeError(task model.MonitorTask, timeout time.Duration, err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "DNS 解析失败: " + dnsErr.Error()
+	}
+	if errors.Is(err, errTooManyRedirects) {
+		return errors.Unwrap(err).Error() + "，可能存在重定向循环"
+	}
+	var opErr *net.OpError
+	if task.SourceIP != "" && errors.As(err, &opErr) && opErr.Op == "dial" {
+		return fmt.Sprintf("经源地址 %s 建立连接失败（请确认该地址仍绑定在本机网卡上）: %v", task.SourceIP, opErr.Err)
+	}
+	if task.UseHTTP3 {
+		return "HTTP/3 (QUIC) 请求失败，可能是 UDP 端口被拦截、握手失败或服务端未启用 HTTP/3: " + err.Error()
+	}
+	if errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout() {
+		return "建立连接超时，主机可能不可达: " + err.Error()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Sprintf("请求超时: %s 内未完成（可通过任务的 timeout 调整）", timeout)
+	}
+	return err.Error()
+}
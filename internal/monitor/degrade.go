@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// isSlowResult 判断一次检查是否成功但响应缓慢。
+func isSlowResult(res model.MonitorResult) bool {
+	return res.IsSuccess && res.Status == "缓慢"
+}
+
+// evaluateSlowLocked 跟踪连续缓慢次数，首次达到 slow_alert_checks 时返回 true 以发送“响应缓慢”预警。
+// 预警时间记录在 DegradedAt 中，直到任务恢复正常（或宕机后恢复）才清除，供随后的宕机告警说明故障演变。
+// 调用前需持有 s.mu。
+func (s *Service) evaluateSlowLocked(res model.MonitorResult, st *model.TaskState) (warn bool, slowCount int) {
+	limit := s.cfg.Get().SlowAlertChecks
+	switch {
+	case isSlowResult(res):
+		st.ConsecutiveSlow++
+	case res.IsSuccess:
+		st.ConsecutiveSlow = 0
+		if !st.IsDown {
+			// 恢复为正常响应：本轮降级结束，下次变慢重新预警
+			st.DegradedAt = time.Time{}
+		}
+		return false, 0
+	default:
+		// 失败时保留预警时间，由宕机告警引用
+		st.ConsecutiveSlow = 0
+		return false, 0
+	}
+	if limit <= 0 || st.IsDown || !st.DegradedAt.IsZero() || st.ConsecutiveSlow < limit {
+		return false, st.ConsecutiveSlow
+	}
+	st.DegradedAt = time.Now()
+	return true, st.ConsecutiveSlow
+}
+
+// degradedNote 生成宕机告警中的故障演变说明，如“此前 10:01 响应缓慢预警，10:05 确认宕机”；未预警时返回空串。
+func degradedNote(degradedAt, downAt time.Time) string {
+	if degradedAt.IsZero() {
+		return ""
+	}
+	layout := "15:04"
+	if degradedAt.YearDay() != downAt.YearDay() || degradedAt.Year() != downAt.Year() {
+		layout = "01-02 15:04"
+	}
+	return fmt.Sprintf("此前 %s 响应缓慢预警，%s 确认宕机", degradedAt.Format(layout), downAt.Format(layout))
+}
+
+// notifyDegraded 记录并发送“响应缓慢”预警（邮件 + Webhook degraded 事件）。
+func (s *Service) notifyDegraded(res model.MonitorResult, slowCount int) {
+	msg := fmt.Sprintf("服务 [%s] 连续 %d 次响应缓慢 (最近耗时 %s)，可能正在劣化，请关注。", res.TaskName, slowCount, res.Duration)
+	s.repo.CreateEvent(&model.EventLog{
+		TaskName:  res.TaskName,
+		Tenant:    res.Tenant,
+		EventTime: time.Now().Format("2006-01-02 15:04:05"),
+		Type:      "🐢 响应缓慢",
+		Message:   msg,
+	})
+	s.notifyTask(res.ID, newWebhookPayload("degraded", res, 0, msg), mailGrouped, fmt.Sprintf("🐢 [预警] %s 响应缓慢", res.TaskName), msg)
+}
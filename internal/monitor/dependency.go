@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+
+	"monitor/internal/model"
+)
+
+// receiveInDependencyOrder 收齐 n 个检查结果，并按依赖深度排序（上游任务在前），
+// 使同一批次中上游的故障状态先于下游更新，下游告警据此判断是否需要抑制。
+func receiveInDependencyOrder(ch <-chan model.MonitorResult, n int, tasks []model.MonitorTask) []model.MonitorResult {
+	out := make([]model.MonitorResult, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, <-ch)
+	}
+	parents := dependencyIndex(tasks)
+	if len(parents) == 0 {
+		return out
+	}
+	depth := make(map[int]int, len(out))
+	for _, r := range out {
+		depth[r.ID] = len(ancestors(parents, r.ID))
+	}
+	sort.SliceStable(out, func(i, j int) bool { return depth[out[i].ID] < depth[out[j].ID] })
+	return out
+}
+
+// dependencyIndex 返回任务 ID 到其上游任务 ID 的映射，只包含配置了 depends_on 的任务。
+// 成环的依赖没有明确的根因，环上的任务按无依赖处理，避免彼此抑制导致告警全部丢失。
+func dependencyIndex(tasks []model.MonitorTask) map[int]int {
+	parents := map[int]int{}
+	for _, t := range tasks {
+		if t.DependsOn > 0 && t.DependsOn != t.ID {
+			parents[t.ID] = t.DependsOn
+		}
+	}
+	var inCycle []int
+	for id := range parents {
+		if p, ok := parents[id]; ok && containsInt(ancestors(parents, p), id) {
+			inCycle = append(inCycle, id)
+		}
+	}
+	for _, id := range inCycle {
+		delete(parents, id)
+	}
+	return parents
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ancestors 沿 depends_on 向上返回任务的全部上游 ID（由近及远）。遇到环时在回到已访问的任务处停止，
+// 上游不存在时链条自然终止。
+func ancestors(parents map[int]int, id int) []int {
+	var out []int
+	seen := map[int]bool{id: true}
+	for {
+		p, ok := parents[id]
+		if !ok || seen[p] {
+			return out
+		}
+		seen[p] = true
+		out = append(out, p)
+		id = p
+	}
+}
+
+// failingAncestorLocked 返回任务上游链中当前处于故障（已确认宕机或最近一次检查失败）的最近一个任务 ID。
+// 调用方需持有 s.mu；已删除或未检查过的上游视为正常。
+func (s *Service) failingAncestorLocked(taskID int) (int, bool) {
+	for _, p := range ancestors(dependencyIndex(s.cfg.Get().Tasks), taskID) {
+		if st, ok := s.states[p]; ok && (st.IsDown || st.ConsecutiveFails > 0) {
+			return p, true
+		}
+	}
+	return 0, false
+}
+
+// suppressedNote 说明告警因哪个上游任务故障而被抑制。
+func (s *Service) suppressedNote(parentID int) string {
+	name := fmt.Sprintf("#%d", parentID)
+	if t, ok := s.cfg.GetTask(parentID); ok {
+		name = t.Name
+	}
+	return fmt.Sprintf("上游任务 %s 故障，已抑制通知", name)
+}
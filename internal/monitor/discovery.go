@@ -0,0 +1,216 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// discoverySource 为任务发现同步使用的 managed_by 标识，只有带该标识的任务会被发现流程增删改。
+const discoverySource = "discovery"
+
+// maxDiscoveryBytes 为发现源响应体的读取上限。
+const maxDiscoveryBytes = 5 << 20
+
+// ErrDiscoveryDisabled 表示未配置任务发现地址。
+var ErrDiscoveryDisabled = errors.New("未配置任务发现地址 discovery_url")
+
+// runDiscoveryLoop 按 discovery_interval_min 定期从 discovery_url 拉取任务列表并同步，未配置地址时空转。
+func (s *Service) runDiscoveryLoop(ctx context.Context) {
+	for {
+		c := s.cfg.Get()
+		if c.DiscoveryURL != "" {
+			if _, err := s.SyncDiscovery(ctx); err != nil {
+				log.Printf("⚠️ 任务发现同步失败，保留现有任务: %v", err)
+			}
+		}
+		interval := time.Duration(c.DiscoveryIntervalMin) * time.Minute
+		if interval <= 0 {
+			interval = 10 * time.Minute
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// SyncDiscovery 立即从发现源拉取任务列表，并以 discovery 来源声明式同步：新增缺失任务、删除已消失的任务，
+// 手动添加或其他来源管理的任务不受影响。拉取或解析失败、以及发现源返回空列表时不做任何改动，
+// 避免一次错误的拉取清空全部已发现任务；个别条目校验失败时跳过该条目（记入 Skipped 并保留其对应的现有任务），其余照常同步。
+func (s *Service) SyncDiscovery(ctx context.Context) (model.ReconcileResult, error) {
+	src := s.cfg.Get().DiscoveryURL
+	if src == "" {
+		return model.ReconcileResult{}, ErrDiscoveryDisabled
+	}
+	tasks, err := fetchDiscoveredTasks(ctx, src)
+	if err != nil {
+		return model.ReconcileResult{}, err
+	}
+	if len(tasks) == 0 {
+		return model.ReconcileResult{}, errors.New("发现源返回空列表，为防止误删任务本次不同步")
+	}
+	result, err := s.cfg.Reconcile(discoverySource, tasks, false, true)
+	if err != nil {
+		return result, err
+	}
+	s.ApplyReconcile(result)
+	skipped := make([]string, len(result.Skipped))
+	for i, sk := range result.Skipped {
+		skipped[i] = sk.Error
+		if s.cfg.Get().MaskSecrets {
+			skipped[i] = config.MaskURLIn(sk.Error, sk.URL)
+		}
+		log.Printf("⚠️ 任务发现跳过无效条目: %s", skipped[i])
+	}
+	if n := len(result.Added) + len(result.Updated) + len(result.Removed) + len(result.Skipped); n > 0 {
+		msg := fmt.Sprintf("从 %s 同步任务：新增 %d、更新 %d、删除 %d。", src, len(result.Added), len(result.Updated), len(result.Removed))
+		if len(result.Skipped) > 0 {
+			msg += fmt.Sprintf("跳过 %d 个无效条目：%s", len(result.Skipped), skipped[0])
+			if len(result.Skipped) > 1 {
+				msg += " 等"
+			}
+		}
+		log.Printf("🔎 %s", msg)
+		s.repo.CreateEvent(&model.EventLog{
+			TaskName:   "任务发现",
+			EventTime:  time.Now().Format("2006-01-02 15:04:05"),
+			Type:       "🔎 任务发现",
+			Message:    msg,
+			IsResolved: true,
+		})
+	}
+	return result, nil
+}
+
+// ApplyReconcile 在声明式同步落盘后清理被删除或更新任务的运行态，并在有新增/更新时立即触发一轮检查。
+func (s *Service) ApplyReconcile(result model.ReconcileResult) {
+	for _, t := range result.Removed {
+		s.RemoveTaskState(t.ID)
+	}
+	for _, t := range result.Updated {
+		s.RemoveTaskState(t.ID)
+	}
+	if len(result.Added)+len(result.Updated) > 0 {
+		s.TriggerNow()
+	}
+}
+
+// fetchDiscoveredTasks 拉取并解析发现源。支持三种格式：
+// JSON 字符串数组（["https://a/health", ...]）、任务对象数组或 {"tasks": [...]}（字段同 config.json 中的任务），
+// 以及 sitemap.xml（<urlset><url><loc>）。
+func fetchDiscoveredTasks(ctx context.Context, src string) ([]model.MonitorTask, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/xml;q=0.9, text/xml;q=0.9")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("拉取发现源失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("发现源返回状态码 %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDiscoveryBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取发现源失败: %v", err)
+	}
+	if len(body) > maxDiscoveryBytes {
+		return nil, fmt.Errorf("发现源内容超过 %d MB 上限", maxDiscoveryBytes>>20)
+	}
+	return parseDiscovered(body)
+}
+
+// parseDiscovered 按内容首字符区分 JSON 与 XML 并解析为任务列表。
+func parseDiscovered(body []byte) ([]model.MonitorTask, error) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(string(body), "\xEF\xBB\xBF"))
+	switch {
+	case strings.HasPrefix(trimmed, "<"):
+		return parseSitemap([]byte(trimmed))
+	case strings.HasPrefix(trimmed, "["), strings.HasPrefix(trimmed, "{"):
+		return parseDiscoveryJSON([]byte(trimmed))
+	}
+	return nil, errors.New("无法识别发现源格式（需为 JSON 或 sitemap.xml）")
+}
+
+func parseDiscoveryJSON(body []byte) ([]model.MonitorTask, error) {
+	if body[0] == '{' {
+		var wrapped struct {
+			Tasks []model.MonitorTask `json:"tasks"`
+		}
+		if err := json.Unmarshal(body, &wrapped); err != nil {
+			return nil, fmt.Errorf("解析发现源 JSON 失败: %v", err)
+		}
+		return withDefaultNames(wrapped.Tasks), nil
+	}
+	var urls []string
+	if err := json.Unmarshal(body, &urls); err == nil {
+		return tasksFromURLs(urls), nil
+	}
+	var tasks []model.MonitorTask
+	if err := json.Unmarshal(body, &tasks); err != nil {
+		return nil, fmt.Errorf("解析发现源 JSON 失败: %v", err)
+	}
+	return withDefaultNames(tasks), nil
+}
+
+// sitemapURLSet 对应 sitemap.xml 的 <urlset>，只关心其中的 <loc>。
+type sitemapURLSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	URLs    []struct {
+		Loc string `xml:"loc"`
+	} `xml:"url"`
+}
+
+func parseSitemap(body []byte) ([]model.MonitorTask, error) {
+	var set sitemapURLSet
+	if err := xml.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("解析 sitemap 失败（暂不支持 sitemap 索引文件）: %v", err)
+	}
+	urls := make([]string, 0, len(set.URLs))
+	for _, u := range set.URLs {
+		urls = append(urls, u.Loc)
+	}
+	return tasksFromURLs(urls), nil
+}
+
+func tasksFromURLs(urls []string) []model.MonitorTask {
+	tasks := make([]model.MonitorTask, 0, len(urls))
+	for _, u := range urls {
+		if u = strings.TrimSpace(u); u != "" {
+			tasks = append(tasks, model.MonitorTask{URL: u})
+		}
+	}
+	return withDefaultNames(tasks)
+}
+
+// withDefaultNames 为未提供名称的发现任务以“主机+路径”命名。
+func withDefaultNames(tasks []model.MonitorTask) []model.MonitorTask {
+	for i := range tasks {
+		if strings.TrimSpace(tasks[i].Name) != "" {
+			continue
+		}
+		name := tasks[i].URL
+		if u, err := url.Parse(tasks[i].URL); err == nil && u.Host != "" {
+			name = u.Host + strings.TrimSuffix(u.Path, "/")
+		}
+		tasks[i].Name = name
+	}
+	return tasks
+}
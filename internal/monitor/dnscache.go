@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dnsStaleGrace 是解析失败时仍可使用过期缓存的最长时间，用于平滑解析器的短暂故障。
+const dnsStaleGrace = 5 * time.Minute
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+// dnsCache 是带 TTL 的进程内 DNS 缓存，供探测客户端的 DialContext 使用，
+// 避免大量同主机任务重复解析；解析失败时在宽限期内回退到过期结果。
+type dnsCache struct {
+	mu       sync.Mutex
+	entries  map[string]dnsEntry
+	ttl      atomic.Int64 // 缓存有效期（纳秒），随配置更新
+	resolver *net.Resolver
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	stale  atomic.Int64 // 解析失败时使用过期结果的次数
+}
+
+func newDNSCache(ttl time.Duration) *dnsCache {
+	c := &dnsCache{entries: map[string]dnsEntry{}, resolver: net.DefaultResolver}
+	c.setTTL(ttl)
+	return c
+}
+
+func (c *dnsCache) setTTL(ttl time.Duration) {
+	c.ttl.Store(int64(ttl))
+}
+
+// lookup 返回主机的地址列表，优先使用未过期的缓存。
+func (c *dnsCache) lookup(ctx context.Context, host string) ([]string, error) {
+	now := time.Now()
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		c.hits.Add(1)
+		return entry.addrs, nil
+	}
+
+	c.misses.Add(1)
+	addrs, err := c.resolver.LookupHost(ctx, host)
+	if err != nil {
+		if ok && now.Before(entry.expires.Add(dnsStaleGrace)) {
+			c.stale.Add(1)
+			return entry.addrs, nil
+		}
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: now.Add(time.Duration(c.ttl.Load()))}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// dialContext 包装 dialer：先经缓存解析主机名，再依次尝试各地址直到连接成功。
+func (c *dnsCache) dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+		addrs, err := c.lookup(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var lastErr error
+		for _, ip := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// stats 返回缓存命中、未命中与回退到过期结果的次数。
+func (c *dnsCache) stats() (hits, misses, stale int64) {
+	return c.hits.Load(), c.misses.Load(), c.stale.Load()
+}
+
+// DNSCacheStats 返回探测用 DNS 缓存的命中统计。
+func (s *Service) DNSCacheStats() (hits, misses, stale int64) {
+	return s.dns.stats()
+}
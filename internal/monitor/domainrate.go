@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// multiLabelSuffixes 是常见的两级公共后缀，其下一级才是可注册域名（如 example.co.uk）。
+// 未引入完整的公共后缀列表，未收录的后缀按最后两级计算。
+var multiLabelSuffixes = map[string]bool{
+	"com.cn": true, "net.cn": true, "org.cn": true, "gov.cn": true, "edu.cn": true, "ac.cn": true,
+	"com.hk": true, "com.tw": true, "com.sg": true, "com.au": true, "net.au": true, "org.au": true,
+	"co.uk": true, "org.uk": true, "ac.uk": true, "gov.uk": true, "co.jp": true, "ne.jp": true, "or.jp": true,
+	"co.kr": true, "co.nz": true, "co.in": true, "com.br": true, "com.mx": true, "co.za": true,
+}
+
+// effectiveDomain 返回地址的可注册域名（如 api.example.com → example.com），用于按域名限速。
+// tcp 任务的 host:port 与 ping 任务的主机名同样按主机名计算。IP 地址与单级主机名原样返回，无法解析的地址返回空串。
+func effectiveDomain(raw string) string {
+	var host string
+	if !strings.Contains(raw, "://") {
+		host = raw
+		if h, _, err := net.SplitHostPort(raw); err == nil {
+			host = h
+		}
+	} else if u, err := url.Parse(raw); err == nil {
+		host = u.Hostname()
+	} else {
+		return ""
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	labels := strings.Split(host, ".")
+	n := 2
+	if len(labels) >= 3 && multiLabelSuffixes[strings.Join(labels[len(labels)-2:], ".")] {
+		n = 3
+	}
+	if len(labels) <= n {
+		return host
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
+
+// tokenBucket 是按分钟速率补充的令牌桶，容量等于每分钟允许的次数。
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// domainLimiter 按可注册域名限制定时检查的频率，并记录任务被连续推迟的次数，
+// 被推迟过的任务在下一轮优先拿到令牌，避免同一批任务长期排在后面。
+type domainLimiter struct {
+	mu       sync.Mutex
+	buckets  map[string]*tokenBucket
+	deferred map[int]int
+	logged   map[string]time.Time // 各域名上次打印限速日志的时间，每分钟至多一条
+}
+
+// take 尝试从域名的令牌桶中取一个令牌，perMin 为该域名每分钟允许的检查次数。
+func (l *domainLimiter) take(domain string, perMin int, now time.Time) bool {
+	b, ok := l.buckets[domain]
+	if !ok {
+		b = &tokenBucket{tokens: float64(perMin), last: now}
+		l.buckets[domain] = b
+	}
+	b.tokens = min(float64(perMin), b.tokens+now.Sub(b.last).Minutes()*float64(perMin))
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// domainRate 返回域名每分钟允许的检查次数：domain_rate_limits 中的单独设置优先，0 表示不限制。
+func domainRate(cfg model.Config, domain string) int {
+	if n, ok := cfg.DomainRateLimits[domain]; ok {
+		return n
+	}
+	return cfg.DomainRatePerMin
+}
+
+// applyDomainRateLimit 按域名速率筛选本轮可派发的任务，超出速率的任务推迟到后续批次（沿用已有结果）。
+// 按优先级与被连续推迟的次数决定谁先拿到令牌；未配置任何域名速率时原样返回。
+func (s *Service) applyDomainRateLimit(tasks []model.MonitorTask) (due []model.MonitorTask, limited []int) {
+	cfg := s.cfg.Get()
+	if cfg.DomainRatePerMin <= 0 && len(cfg.DomainRateLimits) == 0 {
+		return tasks, nil
+	}
+	l := &s.domainLimit
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buckets == nil {
+		l.buckets = map[string]*tokenBucket{}
+		l.deferred = map[int]int{}
+		l.logged = map[string]time.Time{}
+	}
+
+	ordered := append([]model.MonitorTask(nil), tasks...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Priority != ordered[j].Priority {
+			return ordered[i].Priority > ordered[j].Priority
+		}
+		return l.deferred[ordered[i].ID] > l.deferred[ordered[j].ID]
+	})
+
+	now := time.Now()
+	perDomain := map[string]int{}
+	due = make([]model.MonitorTask, 0, len(tasks))
+	for _, t := range ordered {
+		target := t.URL
+		if expanded, err := config.ExpandURL(t.URL, cfg.Vars, t.Vars); err == nil {
+			target = expanded
+		}
+		domain := effectiveDomain(target)
+		rate := domainRate(cfg, domain)
+		if domain == "" || rate <= 0 || l.take(domain, rate, now) {
+			delete(l.deferred, t.ID)
+			due = append(due, t)
+			continue
+		}
+		l.deferred[t.ID]++
+		perDomain[domain]++
+		limited = append(limited, t.ID)
+	}
+	for domain, n := range perDomain {
+		if now.Sub(l.logged[domain]) < time.Minute {
+			continue
+		}
+		l.logged[domain] = now
+		log.Printf("🐢 域名 %s 超出每分钟 %d 次的检查速率，%d 个任务推迟到后续批次", domain, domainRate(cfg, domain), n)
+	}
+	return due, limited
+}
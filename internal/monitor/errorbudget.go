@@ -0,0 +1,99 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// maxOutcomeMinutes 是按分钟统计检查结果的最长保留时间（24 小时）。
+const maxOutcomeMinutes = 24 * 60
+
+// outcomeBucket 记录某一分钟内的检查次数与失败次数。
+type outcomeBucket struct {
+	minute int64 // Unix 分钟数，用于判断槽位是否属于当前这一轮
+	total  int
+	fails  int
+}
+
+// outcomeLog 以分钟为粒度保存单个任务最近 24 小时的检查结果，用于计算任意窗口内的失败率。
+type outcomeLog struct {
+	buckets [maxOutcomeMinutes]outcomeBucket
+}
+
+func (l *outcomeLog) add(at time.Time, ok bool) {
+	minute := at.Unix() / 60
+	b := &l.buckets[minute%maxOutcomeMinutes]
+	if b.minute != minute {
+		*b = outcomeBucket{minute: minute}
+	}
+	b.total++
+	if !ok {
+		b.fails++
+	}
+}
+
+// count 返回截至 now 的最近 window 内的失败次数与检查总数。
+func (l *outcomeLog) count(now time.Time, window time.Duration) (fails, total int) {
+	minutes := int64(window / time.Minute)
+	if minutes <= 0 {
+		minutes = 1
+	}
+	if minutes > maxOutcomeMinutes {
+		minutes = maxOutcomeMinutes
+	}
+	cur := now.Unix() / 60
+	for m := cur - minutes + 1; m <= cur; m++ {
+		b := l.buckets[m%maxOutcomeMinutes]
+		if b.minute == m {
+			fails += b.fails
+			total += b.total
+		}
+	}
+	return fails, total
+}
+
+// evaluateErrorBudgetLocked 记录本次检查结果并评估错误率规则，调用前需持有 s.mu。
+// 失败率首次超标时返回 true；持续超标时按告警冷却时间重复提醒；回落到阈值以下后复位。
+func (s *Service) evaluateErrorBudgetLocked(res model.MonitorResult, st *model.TaskState, cooldown time.Duration) (alert bool, fails, total int) {
+	now := time.Now()
+	ol, ok := s.outcomes[res.ID]
+	if !ok {
+		ol = &outcomeLog{}
+		s.outcomes[res.ID] = ol
+	}
+	ol.add(now, res.IsSuccess)
+
+	rule := s.cfg.Get().ErrorBudget
+	if !rule.Enabled {
+		st.BudgetBreached = false
+		return false, 0, 0
+	}
+	fails, total = ol.count(now, time.Duration(rule.WindowMinutes)*time.Minute)
+	if total < rule.MinSamples || float64(fails)*100 <= rule.MaxFailurePct*float64(total) {
+		st.BudgetBreached = false
+		return false, fails, total
+	}
+	if !st.BudgetBreached || now.Sub(st.LastBudgetAlert) > cooldown {
+		st.BudgetBreached = true
+		st.LastBudgetAlert = now
+		return true, fails, total
+	}
+	return false, fails, total
+}
+
+// notifyErrorBudget 记录错误率超标事件并发送通知。
+func (s *Service) notifyErrorBudget(res model.MonitorResult, fails, total int) {
+	rule := s.cfg.Get().ErrorBudget
+	msg := fmt.Sprintf("服务 [%s] 最近 %d 分钟失败率 %.1f%%（%d/%d），超过阈值 %.1f%%",
+		res.TaskName, rule.WindowMinutes, float64(fails)*100/float64(total), fails, total, rule.MaxFailurePct)
+	s.repo.CreateEvent(&model.EventLog{
+		TaskName:  res.TaskName,
+		Tenant:    res.Tenant,
+		EventTime: time.Now().Format("2006-01-02 15:04:05"),
+		Type:      "📉 错误率超标",
+		Message:   msg,
+	})
+	s.notifyTask(res.ID, newWebhookPayload("error_budget", res, fails, msg), mailGrouped, "📉 [报警] "+res.TaskName+" 错误率超标", msg)
+}
@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// evaluateFlapLocked 记录任务成功/失败状态的切换，并判定是否进入或退出“抖动”状态，调用前需持有 s.mu。
+// 窗口内切换次数达到阈值即视为抖动；最近一次切换之后稳定满 StableMinutes 才解除。
+func (s *Service) evaluateFlapLocked(res *model.MonitorResult, st *model.TaskState) (started, ended bool) {
+	now := time.Now()
+	if st.HasLastOutcome && st.LastSuccess != res.IsSuccess {
+		st.Transitions = append(st.Transitions, now)
+	}
+	st.HasLastOutcome, st.LastSuccess = true, res.IsSuccess
+
+	rule := s.cfg.Get().Flap
+	if !rule.Enabled {
+		st.Transitions, st.Flapping = nil, false
+		return false, false
+	}
+
+	// 只保留窗口内的切换记录
+	cutoff := now.Add(-time.Duration(rule.WindowMinutes) * time.Minute)
+	kept := st.Transitions[:0]
+	for _, t := range st.Transitions {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	st.Transitions = kept
+
+	switch {
+	case !st.Flapping && len(st.Transitions) >= rule.Transitions:
+		st.Flapping = true
+		started = true
+	case st.Flapping:
+		last := st.LastFlapChange
+		if n := len(st.Transitions); n > 0 {
+			last = st.Transitions[n-1]
+		}
+		if now.Sub(last) >= time.Duration(rule.StableMinutes)*time.Minute {
+			st.Flapping = false
+			ended = true
+		}
+	}
+	if started || ended {
+		st.LastFlapChange = now
+	}
+	if st.Flapping {
+		res.Flapping = true
+		res.Status = "抖动"
+	}
+	return started, ended
+}
+
+// notifyFlap 记录抖动开始/结束事件；开始时发送一次通知，结束时仅记录事件。
+func (s *Service) notifyFlap(res model.MonitorResult, started bool) {
+	rule := s.cfg.Get().Flap
+	if !started {
+		s.repo.CreateEvent(&model.EventLog{
+			TaskName:  res.TaskName,
+			Tenant:    res.Tenant,
+			EventTime: time.Now().Format("2006-01-02 15:04:05"),
+			Type:      "🔀 抖动结束",
+			Message:   fmt.Sprintf("服务 [%s] 已稳定 %d 分钟，恢复常规告警。", res.TaskName, rule.StableMinutes),
+		})
+		return
+	}
+	msg := fmt.Sprintf("服务 [%s] %d 分钟内状态反复切换 %d 次以上，判定为抖动，期间暂停常规宕机/恢复告警。",
+		res.TaskName, rule.WindowMinutes, rule.Transitions)
+	s.repo.CreateEvent(&model.EventLog{
+		TaskName:  res.TaskName,
+		Tenant:    res.Tenant,
+		EventTime: time.Now().Format("2006-01-02 15:04:05"),
+		Type:      "🔀 状态抖动",
+		Message:   msg,
+	})
+	s.notifyTask(res.ID, newWebhookPayload("flapping", res, 0, msg), mailGrouped, "🔀 [报警] "+res.TaskName+" 状态抖动", msg)
+}
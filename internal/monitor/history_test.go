@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+	"monitor/internal/repository"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := config.NewManager(filepath.Join(dir, "config.json"))
+	if err := cfg.LoadOrDefault(); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	repo, err := repository.New(filepath.Join(dir, "monitor.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Close() })
+	return New(cfg, repo)
+}
+
+// 同一 URL 的两个任务各自维护历史点阵，删除其中一个不影响另一个。
+func TestHistoryDotsSeparateForDuplicateURLs(t *testing.T) {
+	s := newTestService(t)
+	const url = "https://example.com/health"
+
+	s.processResult(model.MonitorResult{ID: 1, URL: url, IsSuccess: true, StatusColor: "green"}, 3, time.Minute)
+	s.processResult(model.MonitorResult{ID: 2, URL: url, IsSuccess: false, StatusColor: "red"}, 3, time.Minute)
+	got := s.processResult(model.MonitorResult{ID: 1, URL: url, IsSuccess: true, StatusColor: "green"}, 3, time.Minute)
+
+	if want := []string{"green", "green"}; !slices.Equal(got.HistoryDots, want) {
+		t.Fatalf("任务 1 的历史点阵 = %v，期望 %v", got.HistoryDots, want)
+	}
+	if want := []string{"red"}; !slices.Equal(s.history[2], want) {
+		t.Fatalf("任务 2 的历史点阵 = %v，期望 %v", s.history[2], want)
+	}
+
+	s.RemoveTaskState(2)
+	if _, ok := s.history[2]; ok {
+		t.Fatalf("删除任务 2 后其历史点阵仍存在: %v", s.history[2])
+	}
+	if want := []string{"green", "green"}; !slices.Equal(s.history[1], want) {
+		t.Fatalf("删除任务 2 影响了任务 1 的历史点阵: %v", s.history[1])
+	}
+}
@@ -0,0 +1,13 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+)
+
+// newHTTP3Transport 创建经 QUIC 发送请求的传输层，tlsConf 可能为 nil。
+// 默认构建不包含 QUIC 实现，此时为 nil；以 -tags http3 构建时由 http3_quic.go 注册。
+var newHTTP3Transport func(tlsConf *tls.Config) http.RoundTripper
+
+var errHTTP3Unavailable = errors.New("当前程序未包含 HTTP/3 支持，请以 -tags http3 重新构建后再启用 use_http3")
@@ -0,0 +1,32 @@
+//go:build http3
+
+package monitor
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func init() {
+	newHTTP3Transport = func(tlsConf *tls.Config) http.RoundTripper {
+		if tlsConf != nil {
+			tlsConf = tlsConf.Clone()
+		}
+		return &h3Transport{Transport: &http3.Transport{TLSClientConfig: tlsConf}}
+	}
+}
+
+// h3Transport 包装 quic-go 的传输层，将响应协议统一记为 "HTTP/3"。
+type h3Transport struct {
+	*http3.Transport
+}
+
+func (t *h3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.Transport.RoundTrip(req)
+	if resp != nil {
+		resp.Proto = "HTTP/3"
+	}
+	return resp, err
+}
@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// ResolveIncident 响应外部系统（如 ITSM 工单关闭）的“故障已解决”信号：
+// 将任务未解决的宕机事件标记为已解决，并清空宕机相关状态（宕机标记、连续失败次数、故障开始时间、
+// 上次告警时间与抑制标记），下一轮检查从零开始计数；预热、抖动、错误率等其余状态保持不变。
+// 重复调用是幂等的：任务未宕机且没有未解决事件时不做任何改动，也不记录事件。
+// by 为调用方标识，写入审计事件便于追溯是谁解除的。
+func (s *Service) ResolveIncident(task model.MonitorTask, by string) (wasDown bool, resolved int64) {
+	var key string
+	s.mu.Lock()
+	if st, ok := s.states[task.ID]; ok && (st.IsDown || st.ConsecutiveFails > 0) {
+		wasDown = st.IsDown
+		key = incidentKey(task.ID, st.DownSince)
+		st.IsDown = false
+		st.ConsecutiveFails = 0
+		st.DownSince = time.Time{}
+		st.LastAlertTime = time.Time{}
+		st.Suppressed = false
+	}
+	s.mu.Unlock()
+
+	resolved = s.repo.ResolveDownEvents(task.Name)
+	if !wasDown && resolved == 0 {
+		return false, 0
+	}
+	if by == "" {
+		by = "外部系统"
+	}
+	s.repo.CreateEvent(&model.EventLog{
+		TaskName:   task.Name,
+		Tenant:     task.Tenant,
+		EventTime:  time.Now().Format("2006-01-02 15:04:05"),
+		Type:       "🛠️ 外部解除",
+		Message:    fmt.Sprintf("服务 [%s] 的故障已由 %s 标记为解决，告警状态已重置（关闭 %d 条未解决告警）。", task.Name, by, resolved),
+		IsResolved: true,
+		DedupKey:   key,
+	})
+	return wasDown, resolved
+}
+
+// incidentKey 根据任务 ID 与故障开始时间生成稳定的去重键，供下游（PagerDuty/Opsgenie 等）合并同一次故障的事件。
+// since 为零值（任务未处于宕机状态）时返回空串。
+func incidentKey(taskID int, since time.Time) string {
+	if since.IsZero() {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d", taskID, since.UnixNano())))
+	return hex.EncodeToString(sum[:16])
+}
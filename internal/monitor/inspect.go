@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+const (
+	inspectMaxHeaders  = 50  // 快照最多保留的响应头数量
+	inspectMaxValueLen = 256 // 单个响应头值的最大长度，超出部分截断
+)
+
+// sensitiveHeaders 是快照中需要脱敏的响应头（规范化后的名称）。
+var sensitiveHeaders = map[string]bool{
+	"Set-Cookie":          true,
+	"Cookie":              true,
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+}
+
+// isSensitiveHeader 判断响应头是否可能携带凭据，除固定列表外也按名称关键字识别（如 X-Api-Key）。
+func isSensitiveHeader(name string) bool {
+	if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+		return true
+	}
+	lower := strings.ToLower(name)
+	for _, kw := range []string{"token", "secret", "key", "session", "password"} {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotHeaders 将响应头整理为按名称排序、脱敏且有界的列表。
+func snapshotHeaders(h http.Header) ([]model.InspectHeader, bool) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make([]model.InspectHeader, 0, len(names))
+	for _, name := range names {
+		for _, v := range h[name] {
+			if len(out) >= inspectMaxHeaders {
+				return out, true
+			}
+			switch {
+			case isSensitiveHeader(name):
+				v = "[已脱敏]"
+			case len(v) > inspectMaxValueLen:
+				v = v[:inspectMaxValueLen] + "…"
+			}
+			out = append(out, model.InspectHeader{Name: name, Value: v})
+		}
+	}
+	return out, false
+}
+
+// captureFailHeaders 在开启 capture_fail_headers 时为失败结果保存响应头快照（脱敏且有界）。
+func (s *Service) captureFailHeaders(res *model.MonitorResult, h http.Header) {
+	if s.cfg.Get().CaptureFailHeaders {
+		res.FailHeaders, _ = snapshotHeaders(h)
+	}
+}
+
+// encodeHeaders 将响应头快照序列化为事件日志中保存的 JSON，无快照时返回空串。
+func encodeHeaders(headers []model.InspectHeader) string {
+	if len(headers) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(headers)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Inspect 对任务执行一次性调试检查，返回状态行与响应头快照。
+// 与常规检查使用相同的客户端与探测方式，但结果不落库、不更新状态、不触发告警。
+func (s *Service) Inspect(taskID int) (model.InspectResult, error) {
+	task, ok := s.cfg.GetTask(taskID)
+	if !ok {
+		return model.InspectResult{}, fmt.Errorf("未找到指定任务")
+	}
+	if task.Type == model.TaskTypeTCP || task.Type == model.TaskTypePing {
+		return model.InspectResult{}, fmt.Errorf("%s 任务没有 HTTP 响应头可供查看", strings.ToUpper(task.Type))
+	}
+	expanded, err := config.ExpandURL(task.URL, s.cfg.Get().Vars, task.Vars)
+	if err != nil {
+		return model.InspectResult{}, err
+	}
+	task.URL = expanded
+	out := model.InspectResult{TaskID: task.ID, URL: task.URL, Headers: []model.InspectHeader{}}
+	if s.cfg.Get().MaskSecrets {
+		out.URL = config.MaskURL(task.URL)
+	}
+
+	client, err := s.clientFor(task)
+	if err != nil {
+		out.Error = err.Error()
+		return out, nil
+	}
+	timeout := probeTimeout(s.cfg.Get(), task)
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	resp, err := probe(ctx, client, task, false)
+	out.Duration = formatDuration(time.Since(start))
+	if err != nil {
+		out.Error = describeProbeError(task, timeout, err)
+		if s.cfg.Get().MaskSecrets {
+			out.Error = config.MaskURLIn(out.Error, task.URL)
+		}
+		return out, nil
+	}
+	out.Method = resp.Method
+	out.StatusLine = resp.Proto + " " + resp.Status
+	out.StatusCode = resp.StatusCode
+	out.Headers, out.HeadersTruncated = snapshotHeaders(resp.Header)
+	return out, nil
+}
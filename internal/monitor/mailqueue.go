@@ -0,0 +1,200 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mailQueueFile 是待重发邮件的持久化文件，进程重启后继续投递。
+const mailQueueFile = "mail_queue.json"
+
+// mailRetryTick 是重发队列的扫描周期。
+const mailRetryTick = 15 * time.Second
+
+// pendingMail 表示一封发送失败、等待重试的邮件。
+type pendingMail struct {
+	To          string    `json:"to,omitempty"` // 收件人，为空表示全局收件人
+	Subject     string    `json:"subject"`
+	Body        string    `json:"body"`
+	Attempts    int       `json:"attempts"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error"`
+}
+
+// mailQueue 是有界的邮件重发队列，发送失败的告警按指数退避重试，超过上限后丢弃并计数。
+type mailQueue struct {
+	mu      sync.Mutex
+	path    string
+	pending []pendingMail
+	failed  int // 重试耗尽后最终放弃的邮件数
+}
+
+func newMailQueue(path string) *mailQueue {
+	q := &mailQueue{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &q.pending); err != nil {
+			log.Printf("⚠️ 邮件重发队列文件 %s 解析失败，已忽略: %v", path, err)
+			q.pending = nil
+		}
+	}
+	return q
+}
+
+// mailRetryDelay 返回第 attempts 次失败后的退避时长：30s 起步，逐次翻倍，最长 30 分钟。
+func mailRetryDelay(attempts int) time.Duration {
+	delay := 30 * time.Second
+	for i := 1; i < attempts && delay < 30*time.Minute; i++ {
+		delay *= 2
+	}
+	if delay > 30*time.Minute {
+		delay = 30 * time.Minute
+	}
+	return delay
+}
+
+func (q *mailQueue) enqueue(to, subject, body string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, pendingMail{
+		To:          to,
+		Subject:     subject,
+		Body:        body,
+		Attempts:    1,
+		NextAttempt: time.Now().Add(mailRetryDelay(1)),
+		LastError:   err.Error(),
+	})
+	q.saveLocked()
+}
+
+// stats 返回当前待重发数量与最终失败数量。
+func (q *mailQueue) stats() (pending, failed int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending), q.failed
+}
+
+// saveLocked 将队列写入磁盘，调用前需持有锁；写入失败只记录日志，不影响投递。
+func (q *mailQueue) saveLocked() {
+	if len(q.pending) == 0 {
+		_ = os.Remove(q.path)
+		return
+	}
+	data, err := json.Marshal(q.pending)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(q.path, data, 0600); err != nil {
+		log.Printf("⚠️ 邮件重发队列持久化失败: %v", err)
+	}
+}
+
+// deliverMail 发送告警类邮件（to 为空时发给全局收件人），失败时放入重发队列而不是直接丢弃。
+func (s *Service) deliverMail(to, subject, body string) {
+	if s.notificationsMuted(subject) {
+		return
+	}
+	if err := s.sendMailTo(to, subject, body); err != nil {
+		retryTo, retry := retryRecipients(to, err)
+		if !retry {
+			return
+		}
+		log.Printf("⚠️ 邮件发送失败，已加入重发队列: %s: %v", subject, err)
+		s.mailQueue.enqueue(retryTo, subject, body, err)
+	}
+}
+
+// retryRecipients 根据发送错误决定重发的收件人：部分收件人失败时只重发给投递失败的地址
+// （格式无效的地址重发也不会成功，不再重试），retry 为 false 表示无需重发。
+func retryRecipients(to string, err error) (string, bool) {
+	var rerr *RecipientError
+	if !errors.As(err, &rerr) {
+		return to, true
+	}
+	if len(rerr.Failed) == 0 {
+		return "", false
+	}
+	return strings.Join(rerr.Failed, ", "), true
+}
+
+// runMailRetryLoop 周期性重发到期的邮件，直到 ctx 结束。
+func (s *Service) runMailRetryLoop(ctx context.Context) {
+	ticker := time.NewTicker(mailRetryTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.retryPendingMails()
+		}
+	}
+}
+
+// retryPendingMails 逐封重发到期邮件；发送过程不持有队列锁，避免阻塞新邮件入队。
+func (s *Service) retryPendingMails() {
+	q := s.mailQueue
+	maxAttempts := s.cfg.Get().SMTP.RetryMax
+
+	q.mu.Lock()
+	now := time.Now()
+	var due []pendingMail
+	kept := q.pending[:0]
+	for _, m := range q.pending {
+		if now.Before(m.NextAttempt) {
+			kept = append(kept, m)
+			continue
+		}
+		due = append(due, m)
+	}
+	q.pending = kept
+	q.mu.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+
+	var retry []pendingMail
+	gaveUp := 0
+	for _, m := range due {
+		// 总开关关闭期间不重发，邮件原样留在队列中（不计重试次数），重新开启后的下一轮即投递
+		if !s.cfg.Get().NotificationsEnabled {
+			retry = append(retry, m)
+			continue
+		}
+		err := s.sendMailTo(m.To, m.Subject, m.Body)
+		if err == nil {
+			continue
+		}
+		to, again := retryRecipients(m.To, err)
+		if !again {
+			continue
+		}
+		m.To = to
+		m.Attempts++
+		m.LastError = err.Error()
+		if m.Attempts > maxAttempts {
+			gaveUp++
+			log.Printf("❌ 邮件重试 %d 次仍失败，已放弃: %s: %v", maxAttempts, m.Subject, err)
+			continue
+		}
+		m.NextAttempt = time.Now().Add(mailRetryDelay(m.Attempts))
+		retry = append(retry, m)
+	}
+
+	q.mu.Lock()
+	q.pending = append(q.pending, retry...)
+	q.failed += gaveUp
+	q.saveLocked()
+	q.mu.Unlock()
+}
+
+// MailQueueStats 返回邮件重发队列中待重发与最终失败的数量。
+func (s *Service) MailQueueStats() (pending, failed int) {
+	return s.mailQueue.stats()
+}
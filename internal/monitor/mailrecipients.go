@@ -0,0 +1,46 @@
+package monitor
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// RecipientError 表示邮件只投递给了部分收件人：Invalid 为格式无效而跳过的地址，
+// Failed 为服务器拒收或投递出错的地址（可重试），其余收件人已成功收到邮件。
+type RecipientError struct {
+	Invalid []string
+	Failed  []string
+	Err     error // 最近一次投递失败的原因
+}
+
+func (e *RecipientError) Error() string {
+	var parts []string
+	if len(e.Invalid) > 0 {
+		parts = append(parts, "地址无效: "+strings.Join(e.Invalid, ", "))
+	}
+	if len(e.Failed) > 0 {
+		parts = append(parts, fmt.Sprintf("投递失败: %s (%v)", strings.Join(e.Failed, ", "), e.Err))
+	}
+	return "部分收件人未收到邮件，" + strings.Join(parts, "；")
+}
+
+// parseRecipients 解析逗号分隔的收件人列表。整体解析失败时逐个解析，
+// 返回可用地址与无法解析的原始片段，一个错误地址不影响其余收件人。
+func parseRecipients(to string) (valid []*mail.Address, invalid []string) {
+	if list, err := mail.ParseAddressList(to); err == nil {
+		return list, nil
+	}
+	for _, part := range strings.Split(to, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if addr, err := mail.ParseAddress(part); err == nil {
+			valid = append(valid, addr)
+		} else {
+			invalid = append(invalid, part)
+		}
+	}
+	return valid, invalid
+}
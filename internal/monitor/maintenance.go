@@ -0,0 +1,12 @@
+package monitor
+
+import (
+	"fmt"
+
+	"monitor/internal/model"
+)
+
+// maintenanceNote 说明告警因处于维护窗口而被抑制。
+func maintenanceNote(w model.MaintenanceWindow) string {
+	return fmt.Sprintf("处于维护窗口 %s，已抑制通知", w.Name)
+}
@@ -0,0 +1,196 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// ThrottledError 表示同一任务的手动检查过于频繁，Wait 为还需等待的时长。
+type ThrottledError struct {
+	Wait time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("手动检查过于频繁，请 %d 秒后再试", e.WaitSeconds())
+}
+
+// WaitSeconds 返回向上取整的等待秒数，便于填写 Retry-After。
+func (e *ThrottledError) WaitSeconds() int {
+	return int(math.Ceil(e.Wait.Seconds()))
+}
+
+// reserveManualCheck 检查并登记任务的手动检查时间，距上次不足 minInterval 时返回 ThrottledError。
+func (s *Service) reserveManualCheck(taskID int, minInterval time.Duration) error {
+	s.manualMu.Lock()
+	defer s.manualMu.Unlock()
+	now := time.Now()
+	if last, ok := s.lastManual[taskID]; ok {
+		if wait := minInterval - now.Sub(last); wait > 0 {
+			return &ThrottledError{Wait: wait}
+		}
+	}
+	s.lastManual[taskID] = now
+	return nil
+}
+
+// CheckOne 立即检查单个任务，并像定时批次一样更新其展示结果、状态与告警。
+// 同一任务的手动检查受 ManualCheckInterval 限流，定时批次不受影响。
+func (s *Service) CheckOne(taskID int) (model.MonitorResult, error) {
+	c := s.cfg.Get()
+	var task model.MonitorTask
+	found := false
+	for _, t := range c.Tasks {
+		if t.ID == taskID {
+			task, found = t, true
+			break
+		}
+	}
+	if !found {
+		return model.MonitorResult{}, fmt.Errorf("未找到指定任务")
+	}
+	if task.Archived {
+		return model.MonitorResult{}, fmt.Errorf("任务已归档，不参与检查")
+	}
+	if task.Paused {
+		return model.MonitorResult{}, fmt.Errorf("任务已暂停，恢复后才能检查")
+	}
+	if err := s.reserveManualCheck(taskID, time.Duration(c.ManualCheckInterval)*time.Second); err != nil {
+		return model.MonitorResult{}, err
+	}
+
+	threshold, cooldown := alertRules(c)
+	ch := make(chan model.MonitorResult, 1)
+	s.checkURL(task, ch)
+	raw := <-ch
+
+	if !s.lockForManual() {
+		return model.MonitorResult{}, errStopping
+	}
+	defer s.runMu.Unlock()
+	res := s.processResult(raw, threshold, cooldown)
+	s.storeResult(res)
+	return res, nil
+}
+
+// errStopping 表示服务正在优雅退出，手动检查的结果不再处理。
+var errStopping = errors.New("监控服务正在停止，检查结果未处理")
+
+// lockForManual 获取 runMu，使手动检查的状态更新与结果写入不与定时批次交错，
+// 也让 Shutdown 等到这些写入完成；服务已开始退出时释放锁并返回 false。
+// 探测本身在加锁前完成，不会因等待批次而延长。
+func (s *Service) lockForManual() bool {
+	s.runMu.Lock()
+	if s.stopping.Load() {
+		s.runMu.Unlock()
+		return false
+	}
+	return true
+}
+
+// BatchCheckError 描述批量检查中未能执行的任务及原因（不存在、已归档、已暂停或被限流）。
+type BatchCheckError struct {
+	ID    int    `json:"id"`
+	Error string `json:"error"`
+}
+
+// CheckBatch 立即并发检查一组任务（ids 为空时检查全部未归档、未暂停的任务），遵循并发上限，
+// 等待全部完成后返回结果；每个任务同样更新展示结果、状态与告警，并受手动检查限流约束。
+func (s *Service) CheckBatch(ids []int) ([]model.MonitorResult, []BatchCheckError) {
+	c := s.cfg.Get()
+	var tasks []model.MonitorTask
+	var skipped []BatchCheckError
+	minInterval := time.Duration(c.ManualCheckInterval) * time.Second
+	if len(ids) == 0 {
+		for _, t := range activeTasks(c.Tasks) {
+			if !t.Paused {
+				tasks = append(tasks, t)
+			}
+		}
+	} else {
+		byID := make(map[int]model.MonitorTask, len(c.Tasks))
+		for _, t := range c.Tasks {
+			byID[t.ID] = t
+		}
+		seen := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			t, ok := byID[id]
+			switch {
+			case !ok:
+				skipped = append(skipped, BatchCheckError{ID: id, Error: "未找到指定任务"})
+			case t.Archived:
+				skipped = append(skipped, BatchCheckError{ID: id, Error: "任务已归档，不参与检查"})
+			case t.Paused:
+				skipped = append(skipped, BatchCheckError{ID: id, Error: "任务已暂停，恢复后才能检查"})
+			default:
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	allowed := tasks[:0]
+	for _, t := range tasks {
+		if err := s.reserveManualCheck(t.ID, minInterval); err != nil {
+			skipped = append(skipped, BatchCheckError{ID: t.ID, Error: err.Error()})
+			continue
+		}
+		allowed = append(allowed, t)
+	}
+	if len(allowed) == 0 {
+		return []model.MonitorResult{}, skipped
+	}
+
+	threshold, cooldown := alertRules(c)
+	ch := make(chan model.MonitorResult, len(allowed))
+	s.dispatchChecks(allowed, ch)
+	raws := receiveInDependencyOrder(ch, len(allowed), allowed)
+
+	if !s.lockForManual() {
+		for _, t := range allowed {
+			skipped = append(skipped, BatchCheckError{ID: t.ID, Error: errStopping.Error()})
+		}
+		return []model.MonitorResult{}, skipped
+	}
+	defer s.runMu.Unlock()
+	results := make([]model.MonitorResult, 0, len(allowed))
+	for _, raw := range raws {
+		res := s.processResult(raw, threshold, cooldown)
+		s.storeResult(res)
+		results = append(results, res)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].ID < results[j].ID })
+	return results, skipped
+}
+
+// alertRules 返回按配置修正后的告警阈值与冷却时长。
+func alertRules(c model.Config) (int, time.Duration) {
+	threshold := c.AlertThreshold
+	if threshold <= 0 {
+		threshold = 1
+	}
+	cooldown := time.Duration(c.AlertCooldown) * time.Minute
+	if cooldown < 0 {
+		cooldown = 0
+	}
+	return threshold, cooldown
+}
+
+// storeResult 用最新检查结果替换展示列表中的同一任务，不存在时追加。
+func (s *Service) storeResult(res model.MonitorResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.results {
+		if s.results[i].ID == res.ID {
+			s.results[i] = res
+			return
+		}
+	}
+	s.results = append(s.results, res)
+}
@@ -0,0 +1,68 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// MetricsSnapshot 是某一时刻监控状态的一致性快照：结果、宕机集合与计数均在同一次加锁内读取，
+// 供统计与指标接口渲染，避免抓取期间批次更新导致“正常数 + 故障数”与任务列表对不上。
+type MetricsSnapshot struct {
+	TakenAt time.Time
+	LastRun time.Time // 最近一次定时批次完成的时间，尚未完成过批次时为零值
+
+	Results []model.MonitorResult // 最新结果副本（已合并标星）
+	Up      int                   // 最新一次检查成功的任务数
+	Failing int                   // 最新一次检查失败的任务数
+	DownIDs []int                 // 已确认宕机（达到告警阈值）的任务 ID，升序
+
+	ConsecutiveFails map[int]int // 各任务当前连续失败次数，按任务 ID 索引
+
+	BatchSkipped  int64
+	BatchOverruns int64
+	MailPending   int
+	MailFailed    int
+	DNSHits       int64
+	DNSMisses     int64
+	DNSStale      int64
+}
+
+// MetricsSnapshot 在一次加锁内取得结果、任务状态与各项计数，返回彼此一致的快照。
+func (s *Service) MetricsSnapshot() MetricsSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := MetricsSnapshot{
+		TakenAt: time.Now(),
+		LastRun: s.lastRun,
+		Results: make([]model.MonitorResult, len(s.results)),
+		DownIDs: []int{},
+
+		ConsecutiveFails: make(map[int]int, len(s.results)),
+	}
+	copy(snap.Results, s.results)
+	sort.Slice(snap.Results, func(i, j int) bool { return snap.Results[i].ID < snap.Results[j].ID })
+	for i := range snap.Results {
+		r := &snap.Results[i]
+		r.Starred = s.stars[r.ID]
+		if r.IsSuccess {
+			snap.Up++
+		} else {
+			snap.Failing++
+		}
+		if st, ok := s.states[r.ID]; ok {
+			if st.IsDown {
+				snap.DownIDs = append(snap.DownIDs, r.ID)
+			}
+			snap.ConsecutiveFails[r.ID] = st.ConsecutiveFails
+		}
+	}
+	sort.Ints(snap.DownIDs)
+
+	snap.BatchSkipped, snap.BatchOverruns = s.batchSkipped.Load(), s.batchOverruns.Load()
+	snap.MailPending, snap.MailFailed = s.mailQueue.stats()
+	snap.DNSHits, snap.DNSMisses, snap.DNSStale = s.dns.stats()
+	return snap
+}
@@ -0,0 +1,126 @@
+package monitor
+
+import (
+	"log"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// Notifier 是通知渠道的统一抽象，邮件与 Webhook 各自实现，发送方按任务的告警路由扇出到所有启用的渠道。
+type Notifier interface {
+	Channel() string // email / webhook
+	Target() string  // 实际使用的收件人或 Webhook 地址
+	Notify(subject, body string) error
+}
+
+// mailMode 决定邮件渠道的投递方式。
+type mailMode int
+
+const (
+	mailDirect  mailMode = iota // 同步发送并返回结果，用于测试通知
+	mailQueued                  // 异步发送，失败进入重发队列，用于恢复通知
+	mailGrouped                 // 按告警分组窗口合并后投递，用于宕机告警
+)
+
+// mailNotifier 通过 SMTP 发送通知，to 为空时使用全局收件人。
+type mailNotifier struct {
+	s    *Service
+	to   string
+	mode mailMode
+}
+
+func (n mailNotifier) Channel() string { return "email" }
+
+func (n mailNotifier) Target() string {
+	if n.to == "" {
+		return n.s.cfg.Get().SMTP.To
+	}
+	return n.to
+}
+
+func (n mailNotifier) Notify(subject, body string) error {
+	switch n.mode {
+	case mailGrouped:
+		n.s.queueAlertMail(n.to, subject, body)
+	case mailQueued:
+		n.s.deliverMail(n.to, subject, body)
+	default:
+		return n.s.sendMailTo(n.to, subject, body)
+	}
+	return nil
+}
+
+// webhookNotifier 把通知以 JSON POST 到 Webhook 地址，base 提供事件类型与任务信息，subject 填入 subject 字段；
+// base 未带 message 时以 body 填充，已带（如告警摘要）时保留，邮件正文只用于邮件。
+type webhookNotifier struct {
+	target   string
+	secret   string
+	template string
+	base     model.WebhookPayload
+}
+
+func (n webhookNotifier) Channel() string { return "webhook" }
+
+func (n webhookNotifier) Target() string { return n.target }
+
+func (n webhookNotifier) Notify(subject, body string) error {
+	p := n.base
+	p.Subject = subject
+	if p.Message == "" {
+		p.Message = body
+	}
+	p.Time = time.Now().Format("2006-01-02 15:04:05")
+	return postWebhook(n.target, n.secret, n.template, p)
+}
+
+// taskNotifiers 按任务的告警路由返回所有启用的通知渠道：任务级收件人与 alert_webhook 覆盖全局配置。
+// base 为 Webhook 载荷（事件类型、任务与检查结果信息），mode 为邮件的投递方式。
+func (s *Service) taskNotifiers(task model.MonitorTask, base model.WebhookPayload, mode mailMode) []Notifier {
+	cfg := s.cfg.Get()
+	var out []Notifier
+	if cfg.SMTP.Enabled {
+		out = append(out, mailNotifier{s: s, to: task.AlertTo, mode: mode})
+	}
+	if target := webhookTarget(cfg.Webhook, task); target != "" {
+		out = append(out, webhookNotifier{
+			target:   target,
+			secret:   cfg.Webhook.Secret,
+			template: cfg.Webhook.Template,
+			base:     base,
+		})
+	}
+	return out
+}
+
+// notifyTask 经任务的全部通知渠道发送告警或恢复通知，不阻塞调用方；通知总开关关闭时不发送。
+// 任务已被删除时按全局渠道发送。
+func (s *Service) notifyTask(taskID int, base model.WebhookPayload, mode mailMode, subject, body string) {
+	if s.notificationsMuted(subject) {
+		return
+	}
+	// 邮件正文与事件日志中的地址已脱敏，Webhook 载荷同样不能带出凭据
+	if s.cfg.Get().MaskSecrets {
+		base.URL = config.MaskURL(base.URL)
+	}
+	task, _ := s.cfg.GetTask(taskID)
+	for _, n := range s.taskNotifiers(task, base, mode) {
+		go func(n Notifier) {
+			if err := n.Notify(subject, body); err != nil {
+				log.Printf("⚠️ %s 通知发送失败 (%s): %v", n.Channel(), n.Target(), err)
+			}
+		}(n)
+	}
+}
+
+// webhookTarget 返回任务的 Webhook 推送地址：任务配置了 alert_webhook 时使用该地址，否则在全局启用时使用全局地址。
+func webhookTarget(cfg model.WebhookConfig, task model.MonitorTask) string {
+	if task.AlertWebhook != "" {
+		return task.AlertWebhook
+	}
+	if cfg.Enabled {
+		return cfg.URL
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package monitor
+
+import (
+	"time"
+
+	"monitor/internal/model"
+)
+
+// markPausedResults 同步展示结果的暂停标记，并为尚无结果的暂停任务（如进程重启后）补一条占位结果，
+// 使暂停任务始终留在看板上。
+func (s *Service) markPausedResults(tasks []model.MonitorTask) {
+	paused := make(map[int]model.MonitorTask)
+	for _, t := range tasks {
+		if t.Paused {
+			paused[t.ID] = t
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.results {
+		_, ok := paused[s.results[i].ID]
+		s.results[i].Paused = ok
+		delete(paused, s.results[i].ID)
+	}
+	for _, t := range tasks {
+		if _, ok := paused[t.ID]; !ok {
+			continue
+		}
+		s.results = append(s.results, model.MonitorResult{
+			ID:          t.ID,
+			TaskName:    t.Name,
+			Tenant:      t.Tenant,
+			URL:         t.URL,
+			Duration:    "--",
+			Status:      "已暂停",
+			StatusColor: "gray",
+			Paused:      true,
+			HistoryDots: append([]string(nil), s.history[t.ID]...),
+		})
+	}
+}
+
+// SetTaskPaused 在任务暂停或恢复后同步运行态：暂停时保留最近一次结果并标记为暂停；
+// 恢复时清零连续失败计数与恢复观察期，仍处于宕机状态的任务从此刻重新计算告警冷却，避免恢复后立即告警。
+func (s *Service) SetTaskPaused(taskID int, paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.results {
+		if s.results[i].ID == taskID {
+			s.results[i].Paused = paused
+		}
+	}
+	if paused {
+		return
+	}
+	if st, ok := s.states[taskID]; ok {
+		st.ConsecutiveFails = 0
+		st.UpSince = time.Time{}
+		if st.IsDown {
+			st.LastAlertTime = time.Now()
+		}
+	}
+}
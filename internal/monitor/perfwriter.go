@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"time"
+
+	"monitor/internal/model"
+)
+
+// perfQueueSize 是异步性能日志写入队列的容量，队列满时退回同步写入，保证不丢数据。
+const perfQueueSize = 4096
+
+// perfFlushInterval 是异步写入模式下未攒满一批时的最长等待时间。
+const perfFlushInterval = 2 * time.Second
+
+// defaultPerfKeepalive 是开启性能日志去重后，未设置 perf_keepalive_every 时最多连续跳过的样本数。
+const defaultPerfKeepalive = 10
+
+// recordPerformance 保存一条性能日志。perf_write_batch 为 0 时同步写入；
+// 否则交给后台写入协程攒批插入，避免 SQLite 单写者串行拖慢检查批次。
+func (s *Service) recordPerformance(p model.PerformanceLog) {
+	if s.cfg.Get().PerfWriteBatch > 0 {
+		select {
+		case s.perfCh <- p:
+			return
+		default:
+		}
+	}
+	s.repo.CreatePerformance(&p)
+}
+
+// perfSampleDue 判断本次检查是否需要写入性能日志。失败的检查总会写入（标记为失败），
+// 趋势图在故障期间不再留空；检查方式不可用的结果不写入。
+// 任务设置了 perf_dedup_pct 时，成功样本的耗时相对上次写入值的偏离超过该百分比，或自上次写入起已跳过
+// perf_keepalive_every 个样本才写入；检查失败会清除基准，恢复后的首个样本总会写入。
+func (s *Service) perfSampleDue(res model.MonitorResult) bool {
+	if res.Unavailable {
+		return false
+	}
+	task, ok := s.cfg.GetTask(res.ID)
+	if !ok || task.PerfDedupPct <= 0 {
+		return true
+	}
+	keepalive := task.PerfKeepaliveEvery
+	if keepalive <= 0 {
+		keepalive = defaultPerfKeepalive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.states[res.ID]
+	if !ok {
+		st = &model.TaskState{}
+		s.states[res.ID] = st
+	}
+	if !res.IsSuccess {
+		st.PerfStored = false
+		return true
+	}
+	diff := res.DurationInt - st.PerfLastMS
+	if diff < 0 {
+		diff = -diff
+	}
+	if st.PerfStored && st.PerfSkipped < keepalive && diff*100 <= int64(task.PerfDedupPct)*max(st.PerfLastMS, 1) {
+		st.PerfSkipped++
+		return false
+	}
+	st.PerfStored = true
+	st.PerfLastMS = res.DurationInt
+	st.PerfSkipped = 0
+	return true
+}
+
+// runPerfWriter 从队列中收集性能日志并按批写入，攒满一批或等待超过 perfFlushInterval 即落库。
+// perfStop 关闭时排空队列并写入剩余数据，然后关闭 perfDone，供退出流程确认没有丢失写入。
+// 不随 Start 的 ctx 退出：ctx 结束时可能仍有批次在执行，需等 Shutdown 确认批次结束后再停止写入。
+func (s *Service) runPerfWriter() {
+	defer close(s.perfDone)
+	ticker := time.NewTicker(perfFlushInterval)
+	defer ticker.Stop()
+
+	var pending []model.PerformanceLog
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		s.repo.CreatePerformanceBatch(pending, len(pending))
+		pending = nil
+	}
+	for {
+		select {
+		case p := <-s.perfCh:
+			pending = append(pending, p)
+			if len(pending) >= max(s.cfg.Get().PerfWriteBatch, 1) {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.perfStop:
+			for {
+				select {
+				case p := <-s.perfCh:
+					pending = append(pending, p)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package monitor
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// defaultPingCount 是 ping 任务每次检查发送的回显请求数。
+const defaultPingCount = 3
+
+// minPingWait 是单个回显请求等待应答的最短时间。
+const minPingWait = 500 * time.Millisecond
+
+// errPingPermission 表示进程没有创建 ICMP 原始套接字的权限。
+var errPingPermission = errors.New("没有创建 ICMP 原始套接字的权限（需以 root 运行，或执行 setcap cap_net_raw+ep 授予程序 CAP_NET_RAW）")
+
+// pingSeq 为每次检查分配 ICMP 标识符，原始套接字会收到本机所有的 ICMP 应答，据此区分并发的检查。
+var pingSeq atomic.Uint32
+
+// icmpChecksum 计算 ICMP 报文的互联网校验和。
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// echoRequest 构造 ICMP 回显请求。IPv6 的校验和由内核填写。
+func echoRequest(v6 bool, id, seq uint16) []byte {
+	msg := make([]byte, 16)
+	msg[0] = 8
+	if v6 {
+		msg[0] = 128
+	}
+	binary.BigEndian.PutUint16(msg[4:], id)
+	binary.BigEndian.PutUint16(msg[6:], seq)
+	binary.BigEndian.PutUint64(msg[8:], uint64(time.Now().UnixNano()))
+	if !v6 {
+		binary.BigEndian.PutUint16(msg[2:], icmpChecksum(msg))
+	}
+	return msg
+}
+
+// isEchoReply 判断收到的报文是否为本次请求的回显应答。
+func isEchoReply(v6 bool, msg []byte, id, seq uint16) bool {
+	want := byte(0)
+	if v6 {
+		want = 129
+	}
+	return len(msg) >= 8 && msg[0] == want && msg[1] == 0 &&
+		binary.BigEndian.Uint16(msg[4:]) == id && binary.BigEndian.Uint16(msg[6:]) == seq
+}
+
+// pingHost 向 ip 依次发送 count 个回显请求，每个最多等待 wait，返回收到应答的往返时间。
+// 到达 deadline 后不再发送，未发送的请求按丢包计。
+func pingHost(ip net.IP, sourceIP string, count int, wait time.Duration, deadline time.Time) ([]time.Duration, error) {
+	v6 := ip.To4() == nil
+	network := "ip4:icmp"
+	if v6 {
+		network = "ip6:ipv6-icmp"
+	}
+	conn, err := net.ListenPacket(network, sourceIP)
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) {
+			return nil, errPingPermission
+		}
+		return nil, err
+	}
+	defer conn.Close()
+
+	id := uint16(pingSeq.Add(1))
+	dst := &net.IPAddr{IP: ip}
+	buf := make([]byte, 1500)
+	var rtts []time.Duration
+	for i := 0; i < count; i++ {
+		seq := uint16(i + 1)
+		start := time.Now()
+		if !start.Before(deadline) {
+			break
+		}
+		if _, err := conn.WriteTo(echoRequest(v6, id, seq), dst); err != nil {
+			return rtts, err
+		}
+		readBy := start.Add(wait)
+		if readBy.After(deadline) {
+			readBy = deadline
+		}
+		_ = conn.SetReadDeadline(readBy)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				// 超时视为丢包，继续发送下一个
+				break
+			}
+			if addr, ok := from.(*net.IPAddr); ok && addr.IP.Equal(ip) && isEchoReply(v6, buf[:n], id, seq) {
+				rtts = append(rtts, time.Since(start))
+				break
+			}
+		}
+	}
+	return rtts, nil
+}
+
+// checkPing 对 ping 类型任务发送 ICMP 回显请求，以收到应答的平均往返时间作为响应耗时：
+// 全部丢失为故障，部分丢失为黄色“丢包”，其余沿用 HTTP 检查的正常/缓慢规则。
+// 进程缺少原始套接字权限时结果标记为不可用，不计入成功或失败。
+func (s *Service) checkPing(task model.MonitorTask, timeout time.Duration) model.MonitorResult {
+	deadline := time.Now().Add(timeout) // DNS 解析与全部回显请求共用同一超时
+	cfg := s.cfg.Get()
+	expanded, expandErr := config.ExpandURL(task.URL, cfg.Vars, task.Vars)
+	if expandErr == nil {
+		task.URL = expanded
+	}
+	res := model.MonitorResult{
+		ID:         task.ID,
+		TaskName:   task.Name,
+		Tenant:     task.Tenant,
+		URL:        task.URL,
+		LastUpdate: time.Now().Format("15:04:05"),
+		Proto:      "ICMP",
+		Duration:   formatDuration(0),
+	}
+	fail := func(reason string) model.MonitorResult {
+		res.Status, res.StatusColor = "故障", "red"
+		res.FailReason = reason
+		return res
+	}
+	if expandErr != nil {
+		return fail(expandErr.Error())
+	}
+
+	ip := net.ParseIP(strings.Trim(task.URL, "[]"))
+	if ip == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		addrs, err := s.dns.lookup(ctx, task.URL)
+		cancel()
+		if err != nil || len(addrs) == 0 {
+			res.DNSFailure = true
+			if err == nil {
+				err = fmt.Errorf("没有可用地址")
+			}
+			return fail("DNS 解析失败: " + err.Error())
+		}
+		ip = net.ParseIP(addrs[0])
+	}
+
+	count := task.PingCount
+	if count <= 0 {
+		count = defaultPingCount
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return fail(fmt.Sprintf("DNS 解析耗尽了 %s 超时，未发送 ICMP 请求", timeout))
+	}
+	// 剩余时间不足以让每个请求至少等待 minPingWait 时减少请求数，保证整次检查不超过超时
+	count = min(count, max(1, int(remaining/minPingWait)))
+	wait := remaining / time.Duration(count)
+	rtts, err := pingHost(ip, task.SourceIP, count, wait, deadline)
+	if errors.Is(err, errPingPermission) {
+		res.Unavailable = true
+		res.Status, res.StatusColor = "无法检测", "gray"
+		res.FailReason = err.Error()
+		return res
+	}
+	if err != nil && len(rtts) == 0 {
+		return fail("发送 ICMP 请求失败: " + err.Error())
+	}
+
+	res.PacketLoss = int(math.Round(float64(count-len(rtts)) * 100 / float64(count)))
+	if len(rtts) == 0 {
+		return fail(fmt.Sprintf("%d 个 ICMP 请求全部超时（每个等待 %s）", count, wait))
+	}
+	var total time.Duration
+	for _, d := range rtts {
+		total += d
+	}
+	avg := total / time.Duration(len(rtts))
+	res.Duration = formatDuration(avg)
+	res.DurationInt = avg.Milliseconds()
+	res.IsSuccess = true
+	switch {
+	case res.PacketLoss > 0:
+		res.Status, res.StatusColor = fmt.Sprintf("丢包 %d%%", res.PacketLoss), "yellow"
+	case res.DurationInt > 800:
+		res.Status, res.StatusColor = "缓慢", "yellow"
+	default:
+		res.Status, res.StatusColor = "正常", "green"
+	}
+	return res
+}
+
+// notePingUnavailableLocked 判断是否需要记录“无法检测”事件：任务首次因权限不足无法检查时返回 true，
+// 之后不再重复，直到检查恢复可用。调用前需持有 s.mu。
+func (s *Service) notePingUnavailableLocked(res model.MonitorResult, st *model.TaskState) bool {
+	if !res.Unavailable {
+		st.CheckUnavailable = false
+		return false
+	}
+	if st.CheckUnavailable {
+		return false
+	}
+	st.CheckUnavailable = true
+	return true
+}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// maxRetryAfter 限制服务端 Retry-After 可推迟检查的最长时间，避免异常取值让任务长期失去监控。
+const maxRetryAfter = time.Hour
+
+// parseRetryAfter 解析 429/503 响应中的 Retry-After（秒数或 HTTP 日期），返回建议等待时长；
+// 其他状态码、缺失或无法解析时返回 0。结果上限为 maxRetryAfter。
+func parseRetryAfter(statusCode int, h http.Header, now time.Time) time.Duration {
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusServiceUnavailable {
+		return 0
+	}
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	var d time.Duration
+	if sec, err := strconv.Atoi(v); err == nil {
+		d = time.Duration(sec) * time.Second
+	} else if t, err := http.ParseTime(v); err == nil {
+		d = t.Sub(now)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return min(d, maxRetryAfter)
+}
+
+// splitDeferred 将任务分为本轮需要检查的与不参与本轮的（仍处于 Retry-After 推迟期，或仅按需检查）；
+// 后者沿用已有的检查结果，返回值 carried 为这些任务的 ID。
+func (s *Service) splitDeferred(tasks []model.MonitorTask) (due []model.MonitorTask, carried map[int]bool) {
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	due = make([]model.MonitorTask, 0, len(tasks))
+	carried = make(map[int]bool)
+	for _, t := range tasks {
+		st, ok := s.states[t.ID]
+		// 仅按需检查的任务与已暂停的任务不参与定时轮询，保留最近一次的结果
+		if !t.Manual && !t.Paused && (!ok || !now.Before(st.DeferUntil)) {
+			due = append(due, t)
+			continue
+		}
+		carried[t.ID] = true
+	}
+	return due, carried
+}
@@ -0,0 +1,211 @@
+package monitor
+
+import (
+	"sort"
+
+	"monitor/internal/model"
+)
+
+// 汇总树中节点的状态取值，按严重程度递增。
+const (
+	NodeUp       = "up"       // 正常
+	NodeDegraded = "degraded" // 部分异常：任务最近一次检查失败但未确认宕机，或下级存在异常
+	NodeDown     = "down"     // 故障
+	NodeUnknown  = "unknown"  // 尚无检查结果
+)
+
+// StatusNode 是状态汇总树中的一个任务节点，Children 为 depends_on 指向它的下游任务。
+type StatusNode struct {
+	ID         int           `json:"id"`
+	Name       string        `json:"name"`
+	Weight     int           `json:"weight"`
+	SelfStatus string        `json:"self_status"` // 任务自身的检查状态
+	Status     string        `json:"status"`      // 综合下游后的汇总状态
+	Health     float64       `json:"health"`      // 0~1 的健康度，加权模式下综合下游
+	DurationMS int64         `json:"duration_ms"`
+	FailReason string        `json:"fail_reason,omitempty"`
+	Children   []*StatusNode `json:"children,omitempty"`
+}
+
+// StatusGroup 是按项目 (tenant) 划分的一组顶层任务。
+type StatusGroup struct {
+	Tenant string        `json:"tenant"`
+	Status string        `json:"status"`
+	Health float64       `json:"health"`
+	Nodes  []*StatusNode `json:"nodes"`
+}
+
+// StatusTree 是 /api/tree 返回的分层健康汇总。
+type StatusTree struct {
+	Mode   string         `json:"mode"` // worst 或 weighted
+	Status string         `json:"status"`
+	Health float64        `json:"health"`
+	Groups []*StatusGroup `json:"groups"`
+}
+
+// nodeHealth 将单个节点状态换算为健康度，未检查的节点不计入。
+func nodeHealth(status string) float64 {
+	switch status {
+	case NodeUp:
+		return 1
+	case NodeDegraded:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// rollup 汇总一组下级的状态。worst 模式下全部正常为 up、全部故障为 down、其余为 degraded；
+// weighted 模式按权重计算健康度，低于 downPct% 为 down，未满 100% 为 degraded。
+// 没有可判定的下级时返回 unknown。
+func rollup(mode string, downPct int, children []*StatusNode) (string, float64) {
+	var total, healthy float64
+	up, down, known := 0, 0, 0
+	for _, c := range children {
+		if c.Status == NodeUnknown {
+			continue
+		}
+		known++
+		switch c.Status {
+		case NodeUp:
+			up++
+		case NodeDown:
+			down++
+		}
+		w := float64(c.Weight)
+		total += w
+		healthy += w * c.Health
+	}
+	if known == 0 || total == 0 {
+		return NodeUnknown, 0
+	}
+	health := healthy / total
+	if mode == "weighted" {
+		switch {
+		case health >= 1:
+			return NodeUp, health
+		case health*100 < float64(downPct):
+			return NodeDown, health
+		default:
+			return NodeDegraded, health
+		}
+	}
+	switch {
+	case up == known:
+		return NodeUp, health
+	case down == known:
+		return NodeDown, health
+	default:
+		return NodeDegraded, health
+	}
+}
+
+// StatusTree 基于当前结果与 depends_on 关系构建分层健康汇总：顶层按项目分组，
+// 任务挂在其上游任务之下。mode 为空时使用配置的 rollup_mode；tenant 非空时只返回该项目。
+func (s *Service) StatusTree(mode, tenant string) StatusTree {
+	cfg := s.cfg.Get()
+	if mode != "worst" && mode != "weighted" {
+		mode = cfg.RollupMode
+	}
+	snap := s.MetricsSnapshot()
+	results := make(map[int]model.MonitorResult, len(snap.Results))
+	for _, r := range snap.Results {
+		results[r.ID] = r
+	}
+	down := make(map[int]bool, len(snap.DownIDs))
+	for _, id := range snap.DownIDs {
+		down[id] = true
+	}
+
+	tasks := activeTasks(cfg.Tasks)
+	nodes := make(map[int]*StatusNode, len(tasks))
+	for _, t := range tasks {
+		n := &StatusNode{ID: t.ID, Name: t.Name, Weight: t.Weight, SelfStatus: NodeUnknown}
+		if n.Weight <= 0 {
+			n.Weight = 1
+		}
+		if r, ok := results[t.ID]; ok {
+			n.DurationMS = r.DurationInt
+			n.FailReason = r.FailReason
+			switch {
+			case down[t.ID]:
+				n.SelfStatus = NodeDown
+			case !r.IsSuccess:
+				n.SelfStatus = NodeDegraded
+			default:
+				n.SelfStatus = NodeUp
+			}
+		}
+		nodes[t.ID] = n
+	}
+
+	// 已归档或不存在的上游视为无依赖，任务提升为顶层节点
+	parents := dependencyIndex(tasks)
+	groups := map[string]*StatusGroup{}
+	for _, t := range tasks {
+		n := nodes[t.ID]
+		if p, ok := nodes[parents[t.ID]]; ok {
+			p.Children = append(p.Children, n)
+			continue
+		}
+		if tenant != "" && t.Tenant != tenant {
+			continue
+		}
+		g, ok := groups[t.Tenant]
+		if !ok {
+			g = &StatusGroup{Tenant: t.Tenant}
+			groups[t.Tenant] = g
+		}
+		g.Nodes = append(g.Nodes, n)
+	}
+
+	tree := StatusTree{Mode: mode, Groups: []*StatusGroup{}}
+	var groupNodes []*StatusNode
+	for _, g := range groups {
+		for _, n := range g.Nodes {
+			rollupNode(n, mode, cfg.RollupDownPct)
+		}
+		g.Status, g.Health = rollup(mode, cfg.RollupDownPct, g.Nodes)
+		tree.Groups = append(tree.Groups, g)
+		groupNodes = append(groupNodes, &StatusNode{Weight: 1, Status: g.Status, Health: g.Health})
+	}
+	sort.Slice(tree.Groups, func(i, j int) bool { return tree.Groups[i].Tenant < tree.Groups[j].Tenant })
+	tree.Status, tree.Health = rollup(mode, cfg.RollupDownPct, groupNodes)
+	return tree
+}
+
+// rollupNode 自底向上计算节点的汇总状态：任务自身故障时为 down，自身正常而下游异常时为 degraded，
+// 自身尚未检查时取下游的汇总状态。健康度在 worst 模式下取自身与下游的较小值，
+// 加权模式下为自身与下游按各自权重的加权平均。
+func rollupNode(n *StatusNode, mode string, downPct int) {
+	sort.Slice(n.Children, func(i, j int) bool { return n.Children[i].ID < n.Children[j].ID })
+	n.Status, n.Health = n.SelfStatus, nodeHealth(n.SelfStatus)
+	if len(n.Children) == 0 {
+		return
+	}
+	for _, c := range n.Children {
+		rollupNode(c, mode, downPct)
+	}
+	childStatus, childHealth := rollup(mode, downPct, n.Children)
+	switch {
+	case childStatus == NodeUnknown:
+		return
+	case n.SelfStatus == NodeUnknown:
+		n.Status, n.Health = childStatus, childHealth
+		return
+	case n.SelfStatus == NodeUp && childStatus != NodeUp:
+		n.Status = NodeDegraded
+	}
+	if mode != "weighted" {
+		n.Health = min(n.Health, childHealth)
+		return
+	}
+	var childWeight float64
+	for _, c := range n.Children {
+		if c.Status != NodeUnknown {
+			childWeight += float64(c.Weight)
+		}
+	}
+	self := float64(n.Weight)
+	n.Health = (self*n.Health + childWeight*childHealth) / (self + childWeight)
+}
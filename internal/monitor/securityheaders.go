@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// securityBaselinePreset 是 security_headers 中代表“安全基线”预设的关键字。
+const securityBaselinePreset = "baseline"
+
+// securityBaseline 是安全基线预设要求的响应头。Strict-Transport-Security 仅对 https 地址要求。
+var securityBaseline = []string{
+	"Strict-Transport-Security",
+	"X-Content-Type-Options",
+	"X-Frame-Options",
+	"Content-Security-Policy",
+	"Referrer-Policy",
+}
+
+// requiredSecurityHeaders 展开任务要求的安全响应头（baseline 预设展开为基线列表），去重并保持顺序。
+func requiredSecurityHeaders(task model.MonitorTask) []string {
+	var out []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		out = append(out, name)
+	}
+	for _, h := range task.SecurityHeaders {
+		if strings.EqualFold(strings.TrimSpace(h), securityBaselinePreset) {
+			for _, b := range securityBaseline {
+				add(b)
+			}
+			continue
+		}
+		add(h)
+	}
+	return out
+}
+
+// missingSecurityHeaders 返回响应中缺失的安全头。HSTS 只对 https 有意义，http 地址不要求；
+// X-Frame-Options 缺失但 CSP 设置了 frame-ancestors 时视为已满足。target 为展开模板后的实际地址。
+func missingSecurityHeaders(task model.MonitorTask, target string, h http.Header) []string {
+	var missing []string
+	https := strings.HasPrefix(strings.ToLower(target), "https://")
+	for _, name := range requiredSecurityHeaders(task) {
+		if strings.TrimSpace(h.Get(name)) != "" {
+			continue
+		}
+		switch name {
+		case "Strict-Transport-Security":
+			if !https {
+				continue
+			}
+		case "X-Frame-Options":
+			if strings.Contains(strings.ToLower(h.Get("Content-Security-Policy")), "frame-ancestors") {
+				continue
+			}
+		}
+		missing = append(missing, name)
+	}
+	return missing
+}
+
+// evaluateSecurityHeadersLocked 比较本次与上次缺失的安全头，集合变化时返回 true 以记录事件。
+// 检查失败时无法判断响应头，沿用上次的状态。调用前需持有 s.mu。
+func (s *Service) evaluateSecurityHeadersLocked(res model.MonitorResult, st *model.TaskState) (changed bool, previous string) {
+	if !res.IsSuccess || res.Inverted {
+		return false, ""
+	}
+	if task, ok := s.cfg.GetTask(res.ID); ok && len(task.SecurityHeaders) == 0 {
+		// 任务不再要求安全头：静默清除状态，不发送“已补齐”
+		st.MissingHeaders = ""
+		return false, ""
+	}
+	current := strings.Join(res.MissingHeaders, ", ")
+	if current == st.MissingHeaders {
+		return false, ""
+	}
+	previous = st.MissingHeaders
+	st.MissingHeaders = current
+	return true, previous
+}
+
+// notifySecurityHeaders 在缺失的安全头发生变化时记录事件并发送通知；previous 为此前缺失的头（逗号分隔）。
+func (s *Service) notifySecurityHeaders(res model.MonitorResult, previous string) {
+	var typ, msg, subject string
+	if len(res.MissingHeaders) == 0 {
+		typ = "🛡️ 安全头已补齐"
+		msg = fmt.Sprintf("服务 [%s] 的安全响应头已全部具备（此前缺失: %s）。", res.TaskName, previous)
+		subject = fmt.Sprintf("🛡️ [恢复] %s 安全头已补齐", res.TaskName)
+	} else {
+		typ = "🛡️ 安全头缺失"
+		msg = fmt.Sprintf("服务 [%s] 缺少安全响应头: %s", res.TaskName, strings.Join(res.MissingHeaders, ", "))
+		subject = fmt.Sprintf("🛡️ [预警] %s 缺少安全响应头", res.TaskName)
+	}
+	s.repo.CreateEvent(&model.EventLog{
+		TaskName:  res.TaskName,
+		Tenant:    res.Tenant,
+		EventTime: time.Now().Format("2006-01-02 15:04:05"),
+		Type:      typ,
+		Message:   msg,
+	})
+	s.notifyTask(res.ID, newWebhookPayload("security_headers", res, 0, msg), mailGrouped, subject, msg)
+}
@@ -3,13 +3,22 @@
 package monitor
 
 import (
+	"compress/gzip"
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net"
 	"net/http"
+	"net/mail"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"monitor/internal/config"
@@ -24,51 +33,123 @@ type Service struct {
 	cfg  *config.Manager  // 配置管理器，用于获取最新配置
 	repo *repository.Repo // 数据仓储，用于持久化日志
 
-	client *http.Client // 自定义 HTTP 客户端，设置超时和连接池
+	client atomic.Pointer[http.Client] // 共享 HTTP 客户端，每轮批次按最新配置替换，手动检查等路径并发读取，统一经 sharedClient 访问
+	dns    *dnsCache                   // 探测用 DNS 缓存
 
-	mu      sync.RWMutex             // 保护 results、states、history 的并发访问
-	runMu   sync.Mutex               // 防止手动触发和定时循环并发执行 runBatch
-	results []model.MonitorResult    // 当前所有任务的最新检查结果（用于 Web 展示）
-	states  map[int]*model.TaskState // 每个任务的动态状态（失败计数、是否宕机、上次告警时间）
-	history map[string][]string      // 每个 URL 的历史状态颜色点（最近10次）
+	clientMu    sync.Mutex          // 保护 taskClients
+	taskClients map[int]*taskClient // 需要定制传输层的任务专用客户端缓存
+
+	perfCh   chan model.PerformanceLog // 异步写入模式下待落库的性能日志
+	perfStop chan struct{}             // 关闭后后台性能日志写入协程排空队列并退出
+	perfDone chan struct{}             // 后台性能日志写入协程退出后关闭
+
+	mailQueue  *mailQueue // 发送失败的告警邮件重发队列
+	alertGroup alertGroup // 告警邮件分组窗口
+
+	batchQueued   atomic.Bool  // 是否已有一个批次在排队等待
+	batchSkipped  atomic.Int64 // 因上一批次未结束而跳过的次数
+	batchOverruns atomic.Int64 // 批次耗时超过监控间隔的次数
+	stopping      atomic.Bool  // 已开始优雅退出，不再启动新的检查批次
+	stopOnce      sync.Once    // 保证 perfStop 只关闭一次
+
+	scriptSem chan struct{} // 限制同时运行的检查回调脚本数量
+	alertLog  alertLog      // 供 SIEM 采集的 JSON Lines 告警日志
+
+	domainLimit domainLimiter // 按可注册域名限制定时检查频率
+
+	stars   map[int]bool // 已标星的任务 ID（受 mu 保护），持久化在数据库 task_stars 表
+	starMu  sync.Mutex   // 串行化 ToggleStar，数据库写入在 mu 之外进行
+	lastRun time.Time    // 最近一次定时批次写入结果的时间（受 mu 保护）
+
+	manualMu   sync.Mutex        // 保护 lastManual
+	lastManual map[int]time.Time // 每个任务上次手动检查的时间，用于限流
+
+	mu       sync.RWMutex             // 保护 results、states、history 的并发访问
+	runMu    sync.Mutex               // 防止手动触发和定时循环并发执行 runBatch，手动检查处理结果时同样持有
+	results  []model.MonitorResult    // 当前所有任务的最新检查结果（用于 Web 展示）
+	states   map[int]*model.TaskState // 每个任务的动态状态（失败计数、是否宕机、上次告警时间）
+	history  map[int][]string         // 每个任务的历史状态颜色点（最近10次），按任务 ID 区分，同一 URL 的多个任务互不干扰
+	spark    map[int]*sparkRing       // 每个任务最近的检查结果环形缓冲区（迷你趋势图）
+	outcomes map[int]*outcomeLog      // 每个任务按分钟统计的检查结果，用于错误率告警
 }
 
 // New 创建监控服务实例，初始化 HTTP 客户端和内部状态容器。
 func New(cfg *config.Manager, repo *repository.Repo) *Service {
-	return &Service{
-		cfg:     cfg,
-		repo:    repo,
-		client:  buildHTTPClient(cfg.Get().Interval),
-		states:  map[int]*model.TaskState{},
-		history: map[string][]string{},
+	c := cfg.Get()
+	dns := newDNSCache(time.Duration(c.DNSCacheTTL) * time.Second)
+	s := &Service{
+		cfg:      cfg,
+		repo:     repo,
+		dns:      dns,
+		states:   map[int]*model.TaskState{},
+		history:  map[int][]string{},
+		spark:    map[int]*sparkRing{},
+		outcomes: map[int]*outcomeLog{},
+
+		taskClients: map[int]*taskClient{},
+		lastManual:  map[int]time.Time{},
+		mailQueue:   newMailQueue(mailQueueFile),
+		perfCh:      make(chan model.PerformanceLog, perfQueueSize),
+		perfStop:    make(chan struct{}),
+		perfDone:    make(chan struct{}),
+		scriptSem:   make(chan struct{}, maxConcurrentScripts),
+		stars:       loadStars(cfg, repo),
 	}
+	s.client.Store(buildHTTPClient(c.ConnectTimeoutSec, c.MaxRedirects, dns))
+	return s
 }
 
-// 根据配置构建 HTTP 客户端，可调整超时。
-func buildHTTPClient(intervalSec int) *http.Client {
-	// 探测超时不宜超过监控间隔，取 min(interval, 5s) 做基准
-	timeout := 5 * time.Second
-	if intervalSec > 0 {
-		iv := time.Duration(intervalSec) * time.Second
-		if iv < timeout {
-			timeout = iv
-		}
+// dialTimeout 返回建立 TCP 连接的超时：配置了 connect_timeout_sec 时使用该值快速判定主机不可达，
+// 否则不单独限制（仍受整体超时约束）。
+func dialTimeout(connectTimeoutSec int) time.Duration {
+	if connectTimeoutSec > 0 {
+		return time.Duration(connectTimeoutSec) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// defaultProbeTimeout 是任务与全局均未设置超时时的请求超时。
+const defaultProbeTimeout = 5 * time.Second
+
+// probeTimeout 返回任务单次请求的超时：任务的 timeout 优先，其次为全局 request_timeout_sec，
+// 都未设置时取 5 秒，且默认值不超过监控间隔；显式设置的超时按原值生效。
+func probeTimeout(cfg model.Config, task model.MonitorTask) time.Duration {
+	if task.Timeout > 0 {
+		return time.Duration(task.Timeout) * time.Second
+	}
+	if cfg.RequestTimeoutSec > 0 {
+		return time.Duration(cfg.RequestTimeoutSec) * time.Second
 	}
+	if iv := time.Duration(cfg.Interval) * time.Second; iv > 0 && iv < defaultProbeTimeout {
+		return iv
+	}
+	return defaultProbeTimeout
+}
+
+// 根据配置构建 HTTP 客户端，可调整建连超时与最大跳转次数。整体请求超时由每次探测的 context 控制（见 probeTimeout）。
+func buildHTTPClient(connectTimeoutSec, maxRedirects int, dns *dnsCache) *http.Client {
 	return &http.Client{
-		Timeout: timeout,
 		Transport: &http.Transport{
+			DialContext:           dns.dialContext(&net.Dialer{Timeout: dialTimeout(connectTimeoutSec), KeepAlive: 30 * time.Second}),
 			MaxIdleConns:          100,
 			IdleConnTimeout:       90 * time.Second,
 			TLSHandshakeTimeout:   5 * time.Second,
 			ExpectContinueTimeout: 1 * time.Second,
 		},
-		// 避免无限重定向：最多 3 次
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 3 {
-				return http.ErrUseLastResponse
-			}
-			return nil
-		},
+		CheckRedirect: redirectPolicy(maxRedirects),
+	}
+}
+
+// errTooManyRedirects 表示跳转次数超过上限，多为重定向循环或过长的跳转链。
+var errTooManyRedirects = errors.New("跳转次数过多")
+
+// redirectPolicy 返回最多跟随 max 次跳转的 CheckRedirect，超出时以 errTooManyRedirects 使本次检查失败。
+func redirectPolicy(max int) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > max {
+			return fmt.Errorf("%w（超过 %d 次）", errTooManyRedirects, max)
+		}
+		return nil
 	}
 }
 
@@ -80,13 +161,36 @@ func drainAndClose(resp *http.Response) {
 	_ = resp.Body.Close()
 }
 
-func (s *Service) doProbeRequest(method, rawURL string) (*http.Response, error) {
-	req, err := http.NewRequest(method, rawURL, nil)
+// doProbeRequest 按任务的请求头与 Basic 认证凭据发送一次探测请求；body 非空时作为请求体发送，合法 JSON 按 application/json 标注类型。
+// 任务自定义的请求头最后设置，可覆盖默认值；Host 头改写请求的虚拟主机名。
+func doProbeRequest(ctx context.Context, client *http.Client, task model.MonitorTask, method, body string) (*http.Response, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, task.URL, reader)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("User-Agent", "HakimiMonitor/1.0")
-	return s.client.Do(req)
+	if body != "" {
+		if json.Valid([]byte(body)) {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	}
+	for k, v := range task.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if task.BasicAuthUser != "" {
+		req.SetBasicAuth(task.BasicAuthUser, task.BasicAuthPass)
+	}
+	return client.Do(req)
 }
 
 func shouldFallbackToGET(resp *http.Response, err error) bool {
@@ -101,24 +205,137 @@ func shouldFallbackToGET(resp *http.Response, err error) bool {
 		resp.StatusCode >= 500
 }
 
-func (s *Service) probeWithFallback(rawURL string) (int, error) {
-	headResp, headErr := s.doProbeRequest(http.MethodHead, rawURL)
-	if !shouldFallbackToGET(headResp, headErr) {
-		defer drainAndClose(headResp)
-		return headResp.StatusCode, nil
+// maxBodyBytes 是需要检查响应体时最多读取的字节数，避免超大响应占用内存。
+const maxBodyBytes = 1 << 20
+
+// probeResponse 汇总一次探测拿到的响应信息，供状态判定与各类内容断言使用。
+type probeResponse struct {
+	Method        string // 实际生效的请求方法（未指定 method 时为 HEAD 或 GET）
+	Proto         string
+	Status        string // 状态行文本，如 "200 OK"
+	StatusCode    int
+	Header        http.Header
+	Body          []byte // 仅在任务需要检查响应体时读取，最多 maxBodyBytes
+	BodyTruncated bool   // 响应体超过上限被截断
+	TLS           *tls.ConnectionState
+}
+
+func captureResponse(resp *http.Response, wantBody bool) (probeResponse, error) {
+	defer drainAndClose(resp)
+	out := probeResponse{
+		Method:     resp.Request.Method,
+		Proto:      resp.Proto,
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		TLS:        resp.TLS,
 	}
-	drainAndClose(headResp)
+	if wantBody {
+		var reader io.Reader = resp.Body
+		// 传输层只在自己添加 Accept-Encoding 时才自动解压；请求头由任务自定义时需要手动处理 gzip。
+		// 解压后的数据同样受 maxBodyBytes 限制，防止压缩炸弹
+		if !resp.Uncompressed && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+			gz, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				return out, fmt.Errorf("解压 gzip 响应体失败: %v", err)
+			}
+			defer gz.Close()
+			reader = gz
+		}
+		body, err := io.ReadAll(io.LimitReader(reader, maxBodyBytes+1))
+		if err != nil {
+			return out, fmt.Errorf("读取响应体失败: %v", err)
+		}
+		if len(body) > maxBodyBytes {
+			body, out.BodyTruncated = body[:maxBodyBytes], true
+		}
+		out.Body = body
+	}
+	return out, nil
+}
 
-	getResp, getErr := s.doProbeRequest(http.MethodGet, rawURL)
+// probe 探测任务地址（调用方已展开模板）：任务指定了 method 时按该方法发送一次（POST/PUT 附带 body）；
+// 否则默认先发 HEAD，失败或不被支持时回退 GET，任务需要检查响应体时直接使用 GET。
+// HEAD 与回退的 GET（含读取响应体）共享 ctx 的截止时间，总耗时不超过调用方给定的超时。
+func probe(ctx context.Context, client *http.Client, task model.MonitorTask, wantBody bool) (probeResponse, error) {
+	if task.Method != "" {
+		resp, err := doProbeRequest(ctx, client, task, task.Method, task.Body)
+		if err != nil {
+			return probeResponse{}, err
+		}
+		return captureResponse(resp, wantBody)
+	}
+	if !wantBody {
+		headResp, headErr := doProbeRequest(ctx, client, task, http.MethodHead, "")
+		if !shouldFallbackToGET(headResp, headErr) {
+			return captureResponse(headResp, false)
+		}
+		drainAndClose(headResp)
+	}
+
+	getResp, getErr := doProbeRequest(ctx, client, task, http.MethodGet, "")
 	if getErr != nil {
-		return 0, getErr
+		return probeResponse{}, getErr
+	}
+	return captureResponse(getResp, wantBody)
+}
+
+// formatDuration 按量级选择易读的单位展示响应耗时：
+// 不足 1ms 用 µs，不足 1s 用 ms，其余用保留一位小数的 s。排序与图表仍使用毫秒整数 DurationInt。
+func formatDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "0ms"
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+}
+
+// checkStatusCode 判定状态码是否符合预期，返回失败原因：任务设置了 expected_status 时按其集合判定，
+// 否则 200~399 视为成功。
+func checkStatusCode(task model.MonitorTask, code int) string {
+	if task.ExpectedStatus == "" {
+		if code < 200 || code >= 400 {
+			return fmt.Sprintf("状态码异常: %d", code)
+		}
+		return ""
+	}
+	ranges, err := config.ParseStatusRanges(task.ExpectedStatus)
+	if err != nil {
+		return err.Error()
+	}
+	if !ranges.Contains(code) {
+		return fmt.Sprintf("状态码不符合预期: 期望 %s，实际 %d", ranges, code)
 	}
-	defer drainAndClose(getResp)
-	return getResp.StatusCode, nil
+	return ""
+}
+
+// retryBackoff 是重试的基础退避时长，第 N 次重试等待 N 倍。
+const retryBackoff = 200 * time.Millisecond
+
+// minRetryBudget 是发起重试所需的最少剩余时间，剩余时间不足时直接以上一次结果为准。
+const minRetryBudget = 500 * time.Millisecond
+
+func containsStatus(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
 }
 
 // Start 启动监控循环，按配置的间隔定时执行检查。收到 ctx.Done() 时退出。
 func (s *Service) Start(ctx context.Context) {
+	go s.runMailRetryLoop(ctx)
+	go s.runPerfWriter()
+	go s.runBurnRateEvaluator(ctx)
+	go s.runDiscoveryLoop(ctx)
+	s.logCheckScriptStatus()
 	for {
 		select {
 		case <-ctx.Done():
@@ -127,12 +344,18 @@ func (s *Service) Start(ctx context.Context) {
 		}
 
 		c := s.cfg.Get()
+		batchStart := time.Now()
 		s.runOnce(c.Tasks, c.AlertThreshold, c.AlertCooldown)
 
 		interval := c.Interval
 		if interval <= 0 {
 			interval = 5
 		}
+		// 批次耗时超过间隔说明间隔对当前任务量/超时设置过于激进，实际检查周期已被拉长
+		if elapsed := time.Since(batchStart); elapsed > time.Duration(interval)*time.Second {
+			n := s.batchOverruns.Add(1)
+			log.Printf("⏱️ 本轮检查耗时 %s 超过监控间隔 %ds（累计 %d 次），请考虑调大间隔", elapsed.Round(time.Millisecond), interval, n)
+		}
 		select {
 		case <-ctx.Done():
 			return
@@ -148,14 +371,36 @@ func (s *Service) TriggerNow() {
 }
 
 // runOnce 在 runMu 的保护下调用 runBatch，确保同一时间只有一个检查批次在执行。
+// 上一批次仍在执行时按 OverlapPolicy 处理：queue 排队等待（最多合并为一次），skip 直接跳过并计数。
 func (s *Service) runOnce(tasks []model.MonitorTask, threshold, cooldownMin int) {
-	s.runMu.Lock()
+	if !s.runMu.TryLock() {
+		if s.cfg.Get().OverlapPolicy == "skip" || !s.batchQueued.CompareAndSwap(false, true) {
+			n := s.batchSkipped.Add(1)
+			log.Printf("⏭️ 上一批次仍在执行，本次检查已跳过（累计跳过 %d 次）", n)
+			return
+		}
+		s.runMu.Lock()
+		s.batchQueued.Store(false)
+		// 排队期间配置可能已变化，使用最新的任务列表
+		c := s.cfg.Get()
+		tasks, threshold, cooldownMin = c.Tasks, c.AlertThreshold, c.AlertCooldown
+	}
 	defer s.runMu.Unlock()
+	if s.stopping.Load() {
+		return
+	}
 	// 每轮根据最新配置重建客户端（适配间隔/超时变化）
-	s.client = buildHTTPClient(s.cfg.Get().Interval)
+	c := s.cfg.Get()
+	s.dns.setTTL(time.Duration(c.DNSCacheTTL) * time.Second)
+	s.client.Store(buildHTTPClient(c.ConnectTimeoutSec, c.MaxRedirects, s.dns))
 	s.runBatch(tasks, threshold, cooldownMin)
 }
 
+// BatchStats 返回因批次重叠而跳过的次数，以及批次耗时超过监控间隔的次数。
+func (s *Service) BatchStats() (skipped, overruns int64) {
+	return s.batchSkipped.Load(), s.batchOverruns.Load()
+}
+
 // SendStartupCheckMail 发送启动自检邮件，验证 SMTP 配置是否正确。
 func (s *Service) SendStartupCheckMail() error {
 	return s.sendMail("✅ [自检] 系统启动", "邮件服务配置正常！")
@@ -167,6 +412,9 @@ func (s *Service) Results() []model.MonitorResult {
 	defer s.mu.RUnlock()
 	out := make([]model.MonitorResult, len(s.results))
 	copy(out, s.results)
+	for i := range out {
+		out[i].Starred = s.stars[out[i].ID]
+	}
 	return out
 }
 
@@ -179,30 +427,20 @@ func (s *Service) StateSnapshot() map[int]model.TaskState {
 		if st == nil {
 			continue
 		}
-		out[id] = *st
+		cp := *st
+		cp.Transitions = append([]time.Time(nil), st.Transitions...)
+		out[id] = cp
 	}
 	return out
 }
 
-// UpdateStar 在内存结果中同步标星状态，避免前端快速点击时出现跳变。
-// 仅更新已有结果，不影响配置存储。
-func (s *Service) UpdateStar(taskID int, starred bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	for i := range s.results {
-		if s.results[i].ID == taskID {
-			s.results[i].Starred = starred
-		}
-	}
-}
-
 // SyncUpdatedTask 在任务被编辑后同步刷新内存中的展示结果与状态缓存。
 func (s *Service) SyncUpdatedTask(task model.MonitorTask, oldURL string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if oldURL != "" && oldURL != task.URL {
-		delete(s.history, oldURL)
+		delete(s.history, task.ID)
 		delete(s.states, task.ID)
 	}
 
@@ -210,7 +448,6 @@ func (s *Service) SyncUpdatedTask(task model.MonitorTask, oldURL string) {
 		if s.results[i].ID == task.ID {
 			s.results[i].TaskName = task.Name
 			s.results[i].URL = task.URL
-			s.results[i].Starred = task.Starred
 			if oldURL != "" && oldURL != task.URL {
 				s.results[i].HistoryDots = nil
 				s.results[i].Status = "待检测"
@@ -223,11 +460,23 @@ func (s *Service) SyncUpdatedTask(task model.MonitorTask, oldURL string) {
 }
 
 // RemoveTaskState 删除指定任务的所有状态（states、history、results），用于任务删除后清理。
-func (s *Service) RemoveTaskState(taskID int, taskURL string) {
+func (s *Service) RemoveTaskState(taskID int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	delete(s.states, taskID)
-	delete(s.history, taskURL)
+	delete(s.history, taskID)
+	delete(s.spark, taskID)
+	delete(s.outcomes, taskID)
+	s.dropTaskClient(taskID)
+	if s.stars[taskID] {
+		delete(s.stars, taskID)
+		if err := s.repo.SetStarred(taskID, false); err != nil {
+			log.Printf("⚠️ 清理任务 %d 的标星失败: %v", taskID, err)
+		}
+	}
+	s.manualMu.Lock()
+	delete(s.lastManual, taskID)
+	s.manualMu.Unlock()
 
 	// 从结果切片中移除该任务
 	filtered := make([]model.MonitorResult, 0, len(s.results))
@@ -244,9 +493,15 @@ func (s *Service) Reset(repo *repository.Repo) {
 	s.mu.Lock()
 	s.results = nil
 	s.states = map[int]*model.TaskState{}
-	s.history = map[string][]string{}
+	s.history = map[int][]string{}
+	s.spark = map[int]*sparkRing{}
+	s.outcomes = map[int]*outcomeLog{}
 	s.mu.Unlock()
 
+	s.clientMu.Lock()
+	s.taskClients = map[int]*taskClient{}
+	s.clientMu.Unlock()
+
 	s.repo = repo
 }
 
@@ -257,6 +512,25 @@ func (s *Service) Reset(repo *repository.Repo) {
 //	threshold: 连续失败触发告警的次数
 //	cooldownMin: 告警冷却时间（分钟），防止频繁发送同任务告警
 func (s *Service) runBatch(tasks []model.MonitorTask, threshold, cooldownMin int) {
+	tasks = activeTasks(tasks)
+	s.markPausedResults(tasks)
+	if len(tasks) == 0 {
+		// 任务列表为空时清空展示结果，避免看板残留已删除任务；
+		// 清空前重新读取最新配置确认，防止使用过期的任务快照误清空。
+		if len(activeTasks(s.cfg.Get().Tasks)) == 0 {
+			s.mu.Lock()
+			s.results = nil
+			s.mu.Unlock()
+		}
+		return
+	}
+	// 服务端通过 Retry-After 要求推迟的任务与仅按需检查的任务本轮跳过，保留已有结果
+	tasks, carried := s.splitDeferred(tasks)
+	// 超出所在域名检查速率的任务同样推迟到后续批次
+	tasks, limited := s.applyDomainRateLimit(tasks)
+	for _, id := range limited {
+		carried[id] = true
+	}
 	if len(tasks) == 0 {
 		return
 	}
@@ -270,171 +544,564 @@ func (s *Service) runBatch(tasks []model.MonitorTask, threshold, cooldownMin int
 
 	// 并发执行检查，结果通过 channel 收集
 	ch := make(chan model.MonitorResult, len(tasks))
+	s.dispatchChecks(tasks, ch)
+
+	newResults := make([]model.MonitorResult, 0, len(tasks)+len(carried))
+	for _, raw := range receiveInDependencyOrder(ch, len(tasks), tasks) {
+		res := s.processResult(raw, threshold, cooldown)
+		s.runCheckScript(res)
+		newResults = append(newResults, res)
+	}
+
+	// 更新全局结果切片；跳过的任务取此刻的最新结果，避免覆盖批次期间手动检查写入的结果
+	s.mu.Lock()
+	for _, r := range s.results {
+		if carried[r.ID] {
+			newResults = append(newResults, r)
+		}
+	}
+	s.results = newResults
+	s.lastRun = time.Now()
+	s.mu.Unlock()
+}
+
+// dispatchChecks 并发派发检查，结果写入 ch（容量需不小于任务数）。
+// 按优先级从高到低派发；设置了并发上限时，派发循环会在名额用尽时等待，高优先级任务先拿到名额。
+func (s *Service) dispatchChecks(tasks []model.MonitorTask, ch chan<- model.MonitorResult) {
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].Priority > tasks[j].Priority })
+	var sem chan struct{}
+	if n := s.cfg.Get().MaxConcurrency; n > 0 {
+		sem = make(chan struct{}, n)
+	}
 	for _, t := range tasks {
-		go s.checkURL(t, ch)
+		if sem == nil {
+			go s.checkURL(t, ch)
+			continue
+		}
+		sem <- struct{}{}
+		go func(t model.MonitorTask) {
+			defer func() { <-sem }()
+			s.checkURL(t, ch)
+		}(t)
 	}
+}
 
-	newResults := make([]model.MonitorResult, 0, len(tasks))
+// processResult 处理单个检查结果：记录性能日志、更新历史点阵与任务状态，并按需触发告警/恢复通知。
+// 返回补全历史点阵后的结果，供展示使用。
+func (s *Service) processResult(res model.MonitorResult, threshold int, cooldown time.Duration) model.MonitorResult {
+	// 记录性能日志，失败的检查同样写入并带上失败标记（开启了去重的任务只在耗时明显变化或到达保活间隔时写入）
+	if s.perfSampleDue(res) {
+		s.recordPerformance(model.PerformanceLog{
+			TaskID:       res.ID,
+			TaskName:     res.TaskName,
+			Tenant:       res.Tenant,
+			ResponseTime: res.DurationInt,
+			StatusCode:   res.StatusCode,
+			Failed:       !res.IsSuccess,
+			CheckTime:    time.Now().Format("15:04:05"),
+		})
+	}
+
+	// 更新历史点阵（保留最近10次）
+	s.mu.Lock()
+	his := append(s.history[res.ID], res.StatusColor)
+	if len(his) > 10 {
+		his = his[len(his)-10:]
+	}
+	s.history[res.ID] = his
+	res.HistoryDots = append([]string(nil), his...)
 
-	for i := 0; i < len(tasks); i++ {
-		res := <-ch
+	// 获取或创建任务状态
+	st, ok := s.states[res.ID]
+	if !ok {
+		st = &model.TaskState{}
+		s.states[res.ID] = st
+	}
 
-		// 如果检查成功，记录性能日志
-		if res.IsSuccess {
-			s.repo.CreatePerformance(&model.PerformanceLog{
-				TaskID:       res.ID,
-				TaskName:     res.TaskName,
-				ResponseTime: res.DurationInt,
-				CheckTime:    time.Now().Format("15:04:05"),
+	// 检查方式在当前环境不可用（如缺少 ICMP 权限）：不更新失败计数与告警状态，只在首次记录一条说明事件
+	noteUnavailable := s.notePingUnavailableLocked(res, st)
+	if res.Unavailable {
+		s.mu.Unlock()
+		if noteUnavailable {
+			s.repo.CreateEvent(&model.EventLog{
+				TaskName:  res.TaskName,
+				Tenant:    res.Tenant,
+				EventTime: time.Now().Format("2006-01-02 15:04:05"),
+				Type:      "🚫 无法检测",
+				Message:   fmt.Sprintf("服务 [%s] 暂时无法检查，状态不计入成功或失败: %s", res.TaskName, res.FailReason),
 			})
+			log.Printf("🚫 任务 [%s] 无法检查: %s", res.TaskName, res.FailReason)
 		}
+		return res
+	}
+	s.recordSparkLocked(res)
+	st.DeferUntil = time.Time{}
+	if res.RetryAfter > 0 {
+		st.DeferUntil = time.Now().Add(time.Duration(res.RetryAfter) * time.Second)
+	}
 
-		// 更新历史点阵（保留最近10次）
-		s.mu.Lock()
-		his := append(s.history[res.URL], res.StatusColor)
-		if len(his) > 10 {
-			his = his[len(his)-10:]
-		}
-		s.history[res.URL] = his
-		res.HistoryDots = append([]string(nil), his...)
+	shouldAlert := false
+	needRecover := false
+	failCount := 0
+	progression := ""
+	dedupKey := ""
+	firstAlert := false
+	suppressAlert := false // 宕机告警或恢复通知因上游故障或维护窗口只记录不发送
+	suppressNote := ""
+	// 维护窗口内检查与状态机照常运行，只抑制通知
+	window, inMaint := config.ActiveMaintenance(s.cfg.Get().MaintenanceWindows, res.ID, time.Now())
+	if inMaint {
+		res.Maintenance = window.Name
+	}
+	minRecover := time.Duration(s.cfg.Get().MinRecoverSec) * time.Second
+	st.TotalChecks++
 
-		// 获取或创建任务状态
-		st, ok := s.states[res.ID]
-		if !ok {
-			st = &model.TaskState{}
-			s.states[res.ID] = st
+	// 告警/恢复判定逻辑
+	if !res.IsSuccess && st.TotalChecks <= s.cfg.Get().WarmupChecks {
+		// 预热期：新任务（或监控重启后）的前几次失败不计入连续失败，避免目标尚在启动时误报
+		failCount = st.ConsecutiveFails
+	} else if !res.IsSuccess {
+		// 失败：递增连续失败次数
+		st.ConsecutiveFails++
+		// 恢复观察期内再次失败：取消待发的恢复通知，但故障事件并未关闭。此后按同一事件处理——
+		// DownSince 与去重键不变，不重发首次告警，只有距上次告警超过冷却期才会像持续宕机一样再次提醒（有意为之）
+		st.UpSince = time.Time{}
+		failCount = st.ConsecutiveFails
+		// DNS 解析失败多为本地解析器抖动，可配置更高的“软失败”阈值
+		limit := threshold
+		if dnsThreshold := s.cfg.Get().DNSFailThreshold; res.DNSFailure && dnsThreshold > limit {
+			limit = dnsThreshold
 		}
-
-		shouldAlert := false
-		needRecover := false
-		failCount := 0
-
-		// 告警/恢复判定逻辑
-		if !res.IsSuccess {
-			// 失败：递增连续失败次数
-			st.ConsecutiveFails++
-			failCount = st.ConsecutiveFails
-			if st.ConsecutiveFails == threshold {
-				// 首次达到阈值，标记为宕机并触发告警
-				st.IsDown = true
-				shouldAlert = true
-			} else if st.ConsecutiveFails > threshold && time.Since(st.LastAlertTime) > cooldown {
-				// 持续失败且冷却期已过，再次触发告警
+		if !st.IsDown && st.ConsecutiveFails >= limit {
+			// 首次达到阈值，标记为宕机并触发告警
+			st.IsDown = true
+			st.DownSince = time.Now()
+			shouldAlert = true
+			firstAlert = true
+			// 宕机前若曾发出缓慢预警，首次告警注明“何时变慢、何时宕机”
+			progression = degradedNote(st.DegradedAt, time.Now())
+		} else if st.IsDown && time.Since(st.LastAlertTime) > cooldown {
+			// 持续失败（含观察期内中断后的再次失败）且冷却期已过，针对同一事件再次提醒
+			shouldAlert = true
+		} else if st.IsDown && st.Suppressed && !inMaint {
+			// 告警曾因上游故障或维护窗口被抑制：上游已恢复（或窗口已结束）而本任务仍宕机时，不等冷却期立即通知
+			if _, failing := s.failingAncestorLocked(res.ID); !failing {
 				shouldAlert = true
 			}
-			if shouldAlert {
-				st.LastAlertTime = time.Now()
+		}
+		if shouldAlert {
+			st.LastAlertTime = time.Now()
+			dedupKey = incidentKey(res.ID, st.DownSince)
+			// 上游任务故障时根因在上游，本任务的告警只记录不通知；上游恢复后仍宕机则照常通知
+			var suppressedBy int
+			suppressedBy, suppressAlert = s.failingAncestorLocked(res.ID)
+			if suppressAlert {
+				suppressNote = s.suppressedNote(suppressedBy)
+			} else if inMaint {
+				suppressAlert, suppressNote = true, maintenanceNote(window)
 			}
-		} else {
-			// 成功：如果之前是宕机状态，则触发恢复
-			if st.IsDown {
-				needRecover = true
+			if firstAlert || !suppressAlert {
+				// 首次告警记录是否被抑制；之后只要发出过一次通知，恢复时就需要通知
+				st.Suppressed = suppressAlert
 			}
+		}
+	} else if st.IsDown {
+		// 成功：之前是宕机状态时，需持续正常 MinRecoverSec 才确认恢复，防止短暂抖动误发恢复通知；
+		// 观察期内不清零失败计数，再次失败时按同一次故障的冷却规则处理，不会重复首次告警
+		if st.UpSince.IsZero() {
+			st.UpSince = time.Now()
+		}
+		if time.Since(st.UpSince) >= minRecover {
+			needRecover = true
+			suppressAlert = st.Suppressed || inMaint
+			st.Suppressed = false
+			dedupKey = incidentKey(res.ID, st.DownSince)
+			st.DownSince = time.Time{}
 			st.IsDown = false
 			st.ConsecutiveFails = 0
+			st.UpSince = time.Time{}
+			st.DegradedAt = time.Time{}
 		}
-		s.mu.Unlock()
+	} else {
+		st.ConsecutiveFails = 0
+	}
+	slowWarn, slowCount := s.evaluateSlowLocked(res, st)
+	headersChanged, previousMissing := s.evaluateSecurityHeadersLocked(res, st)
+	certWarn := s.evaluateCertExpiryLocked(res, st, cooldown)
+	flapStarted, flapEnded := s.evaluateFlapLocked(&res, st)
+	// 抖动期间状态机与事件记录照常进行（恢复仍需关闭未解决的宕机事件），只是不再发送逐次的宕机/恢复通知
+	flapMuted := st.Flapping
+	budgetAlert, budgetFails, budgetTotal := s.evaluateErrorBudgetLocked(res, st, cooldown)
+	s.mu.Unlock()
 
-		// 处理告警
-		if shouldAlert {
-			msg := fmt.Sprintf("服务 [%s] 确认故障! (连续失败%d次, 响应码:%d)", res.TaskName, failCount, res.StatusCode)
-			s.repo.CreateEvent(&model.EventLog{
-				TaskName:  res.TaskName,
-				EventTime: time.Now().Format("2006-01-02 15:04:05"),
-				Type:      "🔥 宕机警告",
-				Message:   msg,
-			})
-			// 异步发送邮件，避免阻塞主流程
-			go func() {
-				_ = s.sendMail(fmt.Sprintf("🔥 [报警] %s 宕机 (累积失败%d次)", res.TaskName, failCount), msg)
-			}()
-		}
+	// 维护窗口内的抖动、错误预算、缓慢、安全头与证书预警多为计划变更所致，直接跳过
+	if inMaint {
+		flapStarted, flapEnded, budgetAlert, slowWarn, headersChanged, certWarn = false, false, false, false, false, false
+	}
 
-		// 处理恢复
-		if needRecover {
-			msg := fmt.Sprintf("服务 [%s] 已恢复正常。耗时: %s", res.TaskName, res.Duration)
-			s.repo.CreateEvent(&model.EventLog{
-				TaskName:  res.TaskName,
-				EventTime: time.Now().Format("2006-01-02 15:04:05"),
-				Type:      "✅ 故障恢复",
-				Message:   msg,
-			})
-			s.repo.ResolveDownEvents(res.TaskName) // 将历史未恢复的告警标记为已恢复
-			go func() {
-				_ = s.sendMail("✅ [恢复] 服务恢复: "+res.TaskName, msg)
-			}()
+	if flapStarted || flapEnded {
+		s.notifyFlap(res, flapStarted)
+	}
+
+	if budgetAlert {
+		s.notifyErrorBudget(res, budgetFails, budgetTotal)
+	}
+
+	if slowWarn {
+		s.notifyDegraded(res, slowCount)
+	}
+
+	if headersChanged {
+		s.notifySecurityHeaders(res, previousMissing)
+	}
+
+	if certWarn {
+		s.notifyCertExpiry(res)
+	}
+
+	// 处理告警
+	if shouldAlert {
+		msg := fmt.Sprintf("服务 [%s] 确认故障! (连续失败%d次, 响应码:%d)", res.TaskName, failCount, res.StatusCode)
+		if res.Inverted {
+			msg = fmt.Sprintf("服务 [%s] 应关闭但可访问! (连续%d次, 响应码:%d)", res.TaskName, failCount, res.StatusCode)
+		}
+		if res.FailReason != "" {
+			msg += " 原因: " + res.FailReason
 		}
+		if progression != "" {
+			msg += "（" + progression + "）"
+		}
+		if suppressAlert {
+			msg += "（" + suppressNote + "）"
+		}
+		s.repo.CreateEvent(&model.EventLog{
+			TaskName:    res.TaskName,
+			Tenant:      res.Tenant,
+			EventTime:   time.Now().Format("2006-01-02 15:04:05"),
+			Type:        "🔥 宕机警告",
+			Message:     msg,
+			Headers:     encodeHeaders(res.FailHeaders),
+			DedupKey:    dedupKey,
+			BodyPreview: res.BodyPreview,
+		})
+		s.writeAlertLog("alert", res, failCount, dedupKey, suppressAlert || flapMuted, msg)
+		// 异步发送邮件与 Webhook，避免阻塞主流程；邮件按配置合并同一时段的告警，事件日志仍逐条记录
+		if !suppressAlert && !flapMuted {
+			payload := newWebhookPayload("alert", res, failCount, msg)
+			payload.DedupKey, payload.BodyPreview = dedupKey, res.BodyPreview
+			s.notifyTask(res.ID, payload, mailGrouped, fmt.Sprintf("🔥 [报警] %s 宕机 (累积失败%d次)", res.TaskName, failCount), s.alertMailBody(res, failCount, msg))
+		}
+	}
 
-		newResults = append(newResults, res)
+	// 处理恢复
+	if needRecover {
+		msg := fmt.Sprintf("服务 [%s] 已恢复正常。耗时: %s", res.TaskName, res.Duration)
+		if res.Inverted {
+			msg = fmt.Sprintf("服务 [%s] 已重新关闭，不再可访问。", res.TaskName)
+		}
+		s.repo.CreateEvent(&model.EventLog{
+			TaskName:  res.TaskName,
+			Tenant:    res.Tenant,
+			EventTime: time.Now().Format("2006-01-02 15:04:05"),
+			Type:      "✅ 故障恢复",
+			Message:   msg,
+			DedupKey:  dedupKey,
+		})
+		s.repo.ResolveDownEvents(res.TaskName) // 将历史未恢复的告警标记为已恢复
+		s.writeAlertLog("recover", res, 0, dedupKey, suppressAlert || flapMuted, msg)
+		if suppressAlert || flapMuted {
+			// 宕机告警被上游故障抑制过，或仍处于抖动期，恢复同样只记录
+			return res
+		}
+		payload := newWebhookPayload("recover", res, 0, msg)
+		payload.DedupKey = dedupKey
+		s.notifyTask(res.ID, payload, mailQueued, "✅ [恢复] 服务恢复: "+res.TaskName, msg)
 	}
+	return res
+}
 
-	// 更新全局结果切片
-	s.mu.Lock()
-	s.results = newResults
-	s.mu.Unlock()
+// activeTasks 过滤掉已归档的任务，返回需要参与本轮检查的任务。
+func activeTasks(tasks []model.MonitorTask) []model.MonitorTask {
+	out := make([]model.MonitorTask, 0, len(tasks))
+	for _, t := range tasks {
+		if t.Archived {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
 }
 
-// checkURL 对单个任务执行 HTTP GET 请求，生成 MonitorResult。
+// checkURL 对单个任务执行检查并按任务选项修正结果（如反向监控）。
+// 检查失败时按全局 retry_count 短暂退避后重试，所有尝试共享任务的请求超时，全部失败才上报故障。
 // 结果通过 channel 返回，实现并发收集。
 func (s *Service) checkURL(task model.MonitorTask, ch chan<- model.MonitorResult) {
+	cfg := s.cfg.Get()
+	timeout := probeTimeout(cfg, task)
+	deadline := time.Now().Add(timeout)
+	res := s.checkOnce(task, timeout)
+	retries := res.Retries
+	var reasons []string
+	// 反向监控的失败即预期结果，不可用与服务端要求推迟（Retry-After）的结果重试也无意义
+	for !task.InvertStatus && !res.IsSuccess && !res.Unavailable && res.RetryAfter == 0 && retries < cfg.RetryCount {
+		wait := time.Duration(retries+1) * retryBackoff
+		remaining := time.Until(deadline) - wait
+		if remaining < minRetryBudget {
+			break
+		}
+		reasons = append(reasons, fmt.Sprintf("第 %d 次: %s", retries+1, res.FailReason))
+		time.Sleep(wait)
+		retries++
+		res = s.checkOnce(task, remaining)
+		retries += res.Retries
+	}
+	res.Retries = retries
+	res.Attempts = retries + 1
+	res.RetryReasons = reasons
+	if res.IsSuccess && retries > 0 {
+		res.SucceededAttempt = res.Attempts
+	}
+	res.TimeoutSec = int(timeout.Round(time.Second) / time.Second)
+
+	switch {
+	case res.Unavailable:
+		// 无法检测（如缺少 ping 权限）的结果既不代表可达也不代表不可达，不参与反向判定与 SLA 统计
+	case task.InvertStatus:
+		invertResult(&res)
+	case task.SLATargetMS > 0:
+		res.SLATargetMS = task.SLATargetMS
+		res.SLAMet = res.IsSuccess && res.DurationInt <= task.SLATargetMS
+	}
+	// 失败原因可能内嵌完整地址（如 Get "https://user@host/?token=..."），落入事件前先脱敏
+	if s.cfg.Get().MaskSecrets {
+		res.FailReason = config.MaskURLIn(res.FailReason, res.URL)
+	}
+	ch <- res
+}
+
+// checkOnce 按任务类型执行一次检查，timeout 为本次尝试可用的时间。
+func (s *Service) checkOnce(task model.MonitorTask, timeout time.Duration) model.MonitorResult {
+	switch task.Type {
+	case model.TaskTypeTCP:
+		return s.checkTCP(task, timeout)
+	case model.TaskTypePing:
+		return s.checkPing(task, timeout)
+	default:
+		return s.runCheck(task, timeout)
+	}
+}
+
+// invertResult 翻转反向监控任务的成功判定：不可访问视为正常，可访问视为故障。
+func invertResult(res *model.MonitorResult) {
+	res.Inverted = true
+	if res.IsSuccess {
+		res.IsSuccess = false
+		res.Status, res.StatusColor = "应关闭但可访问", "red"
+		res.FailReason = fmt.Sprintf("目标应不可访问，但返回了状态码 %d", res.StatusCode)
+		return
+	}
+	res.IsSuccess = true
+	res.Status, res.StatusColor = "已关闭", "green"
+	res.FailReason = ""
+}
+
+// runCheck 对单个任务执行 HTTP 探测（HEAD 优先，必要时回退 GET），生成原始 MonitorResult。
+func (s *Service) runCheck(task model.MonitorTask, timeout time.Duration) model.MonitorResult {
 	start := time.Now()
+	// 模板地址在检查时按最新变量展开，结果中展示展开后的实际地址
+	expanded, expandErr := config.ExpandURL(task.URL, s.cfg.Get().Vars, task.Vars)
+	if expandErr == nil {
+		task.URL = expanded
+	}
 	res := model.MonitorResult{
 		ID:         task.ID,
 		TaskName:   task.Name,
+		Tenant:     task.Tenant,
 		URL:        task.URL,
-		Starred:    task.Starred, // 把星星状态复制给结果
 		LastUpdate: time.Now().Format("15:04:05"),
 	}
 
+	if expandErr != nil {
+		res.Status, res.StatusColor = "故障", "red"
+		res.Duration = formatDuration(0)
+		res.FailReason = expandErr.Error()
+		return res
+	}
+
 	// 预先验证 URL 格式，避免无效请求
 	if _, err := url.ParseRequestURI(task.URL); err != nil {
 		res.Status, res.StatusColor = "故障", "red"
-		res.Duration = "0ms"
-		ch <- res
-		return
+		res.Duration = formatDuration(0)
+		res.FailReason = "URL 格式不合法"
+		return res
 	}
 
-	statusCode, err := s.probeWithFallback(task.URL)
-	ms := time.Since(start).Milliseconds()
-	res.Duration = fmt.Sprintf("%dms", ms)
+	client, err := s.clientFor(task)
+	if err != nil {
+		res.Status, res.StatusColor = "故障", "red"
+		res.Duration = formatDuration(0)
+		res.FailReason = err.Error()
+		return res
+	}
+
+	wantBody := needsBody(task)
+	// 首次请求与可重试状态码的重试共享本次尝试的截止时间
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	resp, err := probe(ctx, client, task, wantBody)
+	// 命中可重试状态码（如负载均衡瞬时 502）时，短暂退避后重新探测；耗时以最后一次尝试为准。
+	// 重试与首次请求共享本次尝试的超时，剩余时间不足 minRetryBudget 时不再重试
+	retryCount := s.cfg.Get().RetryCount
+	for err == nil && res.Retries < retryCount && containsStatus(task.RetryOnStatus, resp.StatusCode) {
+		remaining := time.Until(deadline)
+		if remaining < minRetryBudget {
+			break
+		}
+		res.Retries++
+		time.Sleep(min(time.Duration(res.Retries)*retryBackoff, remaining-minRetryBudget))
+		start = time.Now()
+		resp, err = probe(ctx, client, task, wantBody)
+	}
+	elapsed := time.Since(start)
+	ms := elapsed.Milliseconds()
+	res.Duration = formatDuration(elapsed)
 	res.DurationInt = ms
-	res.StatusCode = statusCode
+	res.StatusCode = resp.StatusCode
 
 	if err != nil {
 		// 网络错误、超时等视为故障
 		res.Status, res.StatusColor = "故障", "red"
-		ch <- res
-		return
+		res.FailReason = describeProbeError(task, timeout, err)
+		var dnsErr *net.DNSError
+		res.DNSFailure = errors.As(err, &dnsErr)
+		return res
 	}
 
-	if statusCode >= 200 && statusCode < 400 {
-		res.IsSuccess = true
-		if ms > 800 {
-			// 响应时间超过800ms标记为“缓慢”
-			res.Status, res.StatusColor = "缓慢", "yellow"
-		} else {
-			res.Status, res.StatusColor = "正常", "green"
+	res.Proto = resp.Proto
+	s.applyCertInfo(task, resp, &res)
+
+	if reason := checkStatusCode(task, resp.StatusCode); reason != "" {
+		res.Status, res.StatusColor = "故障", "red"
+		res.FailReason = reason
+		s.captureFailHeaders(&res, resp.Header)
+		if d := parseRetryAfter(resp.StatusCode, resp.Header, time.Now()); d > 0 {
+			res.RetryAfter = int(d.Round(time.Second) / time.Second)
+			res.FailReason += fmt.Sprintf("（服务端要求 %d 秒后重试，期间暂停检查）", res.RetryAfter)
 		}
-	} else {
+		return res
+	}
+
+	// 请求虽已完成，但耗时超过任务可接受的上限，与宕机同等对待（不同于客户端超时）
+	if task.MaxResponseMS > 0 && !task.InvertStatus && ms > task.MaxResponseMS {
 		res.Status, res.StatusColor = "故障", "red"
+		res.FailReason = fmt.Sprintf("响应超时阈值: 耗时 %dms，超过上限 %dms", ms, task.MaxResponseMS)
+		s.captureFailHeaders(&res, resp.Header)
+		return res
 	}
-	ch <- res
+
+	// 状态码正常但内容不符合预期（如残缺的 JSON）同样视为故障
+	if reason := assertResponse(task, res.URL, resp); reason != "" {
+		res.Status, res.StatusColor = "故障", "red"
+		res.FailReason = reason
+		s.captureFailHeaders(&res, resp.Header)
+		s.captureBodyPreview(&res, resp.Body)
+		return res
+	}
+	if rule, reason := checkKeywords(task, resp); reason != "" {
+		res.Status, res.StatusColor = "故障", "red"
+		res.FailReason = reason
+		res.KeywordRule = rule
+		s.captureFailHeaders(&res, resp.Header)
+		s.captureBodyPreview(&res, resp.Body)
+		return res
+	}
+
+	res.IsSuccess = true
+	if ms > 800 {
+		// 响应时间超过800ms标记为“缓慢”
+		res.Status, res.StatusColor = "缓慢", "yellow"
+	} else {
+		res.Status, res.StatusColor = "正常", "green"
+	}
+	// 缺少要求的安全头只降级为黄色，不计入故障；“缓慢”状态保持不变以免影响缓慢预警
+	if len(task.SecurityHeaders) > 0 && !task.InvertStatus {
+		res.MissingHeaders = missingSecurityHeaders(task, res.URL, resp.Header)
+		if len(res.MissingHeaders) > 0 && res.Status == "正常" {
+			res.Status, res.StatusColor = "安全头缺失", "yellow"
+		}
+	}
+	return res
+}
+
+// notificationsMuted 判断是否已通过全局总开关关闭所有通知（事件仍照常记录）。
+func (s *Service) notificationsMuted(what string) bool {
+	if s.cfg.Get().NotificationsEnabled {
+		return false
+	}
+	log.Printf("🔕 通知已全局关闭，跳过发送: %s", what)
+	return true
 }
 
 // sendMail 通过 SMTP 发送邮件，使用配置中的账号信息。
 // 如果 SMTP 未启用，则直接返回 nil 不发送。
 func (s *Service) sendMail(subject, body string) error {
+	return s.sendMailTo("", subject, body)
+}
+
+// sendMailTo 发送邮件到指定收件人（逗号分隔），to 为空时使用全局收件人。
+// 多个收件人合并发送失败时改为逐个发送，保证有效地址仍能收到告警；
+// 只有部分收件人失败时返回 *RecipientError，列出未收到的地址。
+func (s *Service) sendMailTo(to, subject, body string) error {
 	cfg := s.cfg.Get().SMTP
 	if !cfg.Enabled {
 		return nil
 	}
-	m := gomail.NewMessage()
-	m.SetHeader("From", cfg.Username)
-	m.SetHeader("To", cfg.To)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/plain", body+"\r\n\r\n----------------\r\n来自：哈基米监控系统")
+	if to == "" {
+		to = cfg.To
+	}
+	valid, invalid := parseRecipients(to)
+	if len(valid) == 0 {
+		return fmt.Errorf("没有可用的收件人地址: %q", to)
+	}
 
 	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password)
 	d.TLSConfig = &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
-	return d.DialAndSend(m)
+	send := func(rcpts ...*mail.Address) error {
+		m := gomail.NewMessage()
+		m.SetHeader("From", cfg.Username)
+		addrs := make([]string, len(rcpts))
+		for i, a := range rcpts {
+			addrs[i] = m.FormatAddress(a.Address, a.Name)
+		}
+		m.SetHeader("To", addrs...)
+		m.SetHeader("Subject", subject)
+		m.SetBody("text/plain", body+"\r\n\r\n----------------\r\n来自：哈基米监控系统")
+		return d.DialAndSend(m)
+	}
+
+	err := send(valid...)
+	if err != nil && len(valid) == 1 {
+		return err
+	}
+	var failed []string
+	if err != nil {
+		// 合并发送失败可能只是个别地址被拒收，逐个重发以免连累其他收件人
+		log.Printf("⚠️ 邮件合并发送给 %d 位收件人失败，改为逐个发送: %v", len(valid), err)
+		for _, a := range valid {
+			if e := send(a); e != nil {
+				failed = append(failed, a.Address)
+				err = e
+			}
+		}
+		if len(failed) == len(valid) {
+			return err
+		}
+	}
+	if len(failed) > 0 || len(invalid) > 0 {
+		rerr := &RecipientError{Invalid: invalid, Failed: failed, Err: err}
+		log.Printf("⚠️ %s: %v", subject, rerr)
+		return rerr
+	}
+	return nil
 }
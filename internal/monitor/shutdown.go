@@ -0,0 +1,58 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// minShutdownTimeout 为退出等待时长的下限，shutdownMargin 为批次结束后留给性能日志落库的余量。
+const (
+	minShutdownTimeout = 15 * time.Second
+	shutdownMargin     = 5 * time.Second
+)
+
+// ShutdownTimeout 按当前配置估算优雅退出需要等待的最长时间。单个任务的全部尝试（含重试与退避）
+// 共享其请求超时，一批检查最多耗时为最慢任务的超时乘以受并发上限约束的派发轮数，
+// 再加上落库余量，且不少于 15 秒。
+func (s *Service) ShutdownTimeout() time.Duration {
+	cfg := s.cfg.Get()
+	tasks := activeTasks(cfg.Tasks)
+	var slowest time.Duration
+	for _, task := range tasks {
+		slowest = max(slowest, probeTimeout(cfg, task))
+	}
+	waves := 1
+	if n := cfg.MaxConcurrency; n > 0 && len(tasks) > n {
+		waves = (len(tasks) + n - 1) / n
+	}
+	return max(minShutdownTimeout, slowest*time.Duration(waves)+shutdownMargin)
+}
+
+// Shutdown 优雅停止监控服务：不再启动新的检查批次，等待正在执行的批次结束，
+// 再让性能日志写入协程排空队列落库。调用前应先取消传给 Start 的 ctx 并关闭 HTTP 服务，
+// 避免新的手动检查继续进入。ctx 超时时返回错误，此时仍可能有未完成的写入。
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.stopping.Store(true)
+
+	// 拿到 runMu 即说明当前批次已结束；此后拿到锁的排队批次会在 stopping 检查处直接返回
+	locked := make(chan struct{})
+	go func() {
+		s.runMu.Lock()
+		s.runMu.Unlock()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-ctx.Done():
+		return fmt.Errorf("等待检查批次结束超时: %w", ctx.Err())
+	}
+
+	s.stopOnce.Do(func() { close(s.perfStop) })
+	select {
+	case <-s.perfDone:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待性能日志落库超时: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"monitor/internal/model"
+)
+
+// maxSparkTasks 限制保留迷你趋势图数据的任务数量，防止任务异常增多时内存无限增长。
+const maxSparkTasks = 1000
+
+// sparkRing 是固定容量的环形缓冲区，保存单个任务最近的检查结果。
+type sparkRing struct {
+	points []model.SparkPoint
+	next   int  // 下一个写入位置
+	full   bool // 是否已写满一圈
+}
+
+func newSparkRing(size int) *sparkRing {
+	return &sparkRing{points: make([]model.SparkPoint, size)}
+}
+
+func (r *sparkRing) add(p model.SparkPoint) {
+	r.points[r.next] = p
+	r.next = (r.next + 1) % len(r.points)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot 按时间正序返回缓冲区内容的副本。
+func (r *sparkRing) snapshot() []model.SparkPoint {
+	if !r.full {
+		return append([]model.SparkPoint(nil), r.points[:r.next]...)
+	}
+	out := make([]model.SparkPoint, 0, len(r.points))
+	out = append(out, r.points[r.next:]...)
+	return append(out, r.points[:r.next]...)
+}
+
+// recordSparkLocked 将检查结果写入任务的环形缓冲区，调用前需持有 s.mu。
+// 缓冲区容量随配置变化时丢弃旧数据重新开始。
+func (s *Service) recordSparkLocked(res model.MonitorResult) {
+	size := s.cfg.Get().SparklineSize
+	ring, ok := s.spark[res.ID]
+	if !ok || len(ring.points) != size {
+		if !ok && len(s.spark) >= maxSparkTasks {
+			return
+		}
+		ring = newSparkRing(size)
+		s.spark[res.ID] = ring
+	}
+	ring.add(model.SparkPoint{Time: res.LastUpdate, MS: res.DurationInt, OK: res.IsSuccess})
+}
+
+// Sparkline 返回任务最近的检查结果（时间正序），供迷你趋势图直接渲染。
+// 进程重启后缓冲区为空，首次请求时从性能日志懒加载（日志只记录成功的检查）。
+func (s *Service) Sparkline(taskID int) []model.SparkPoint {
+	s.mu.RLock()
+	ring, ok := s.spark[taskID]
+	var out []model.SparkPoint
+	if ok {
+		out = ring.snapshot()
+	}
+	s.mu.RUnlock()
+	if ok {
+		return out
+	}
+
+	size := s.cfg.Get().SparklineSize
+	logs := s.repo.QueryPerformance(taskID, size)
+	ring = newSparkRing(size)
+	for i := len(logs) - 1; i >= 0; i-- {
+		ring.add(model.SparkPoint{Time: logs[i].CheckTime, MS: logs[i].ResponseTime, OK: !logs[i].Failed})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.spark[taskID]; ok {
+		// 加载期间已有新结果写入，以实时数据为准
+		return existing.snapshot()
+	}
+	if len(s.spark) < maxSparkTasks && len(logs) > 0 {
+		s.spark[taskID] = ring
+	}
+	return ring.snapshot()
+}
@@ -0,0 +1,71 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+
+	"monitor/internal/config"
+	"monitor/internal/repository"
+)
+
+// loadStars 读取已标星任务；旧版本写在任务配置中的标星会先迁移到数据库，
+// 写入成功的才从配置中清除，失败的留待下次启动重试。
+func loadStars(cfg *config.Manager, repo *repository.Repo) map[int]bool {
+	var migrated []int
+	for _, id := range cfg.LegacyStars() {
+		if err := repo.SetStarred(id, true); err != nil {
+			log.Printf("⚠️ 迁移任务 %d 的标星失败: %v", id, err)
+			continue
+		}
+		migrated = append(migrated, id)
+	}
+	if len(migrated) > 0 {
+		if err := cfg.ClearLegacyStars(migrated); err != nil {
+			log.Printf("⚠️ 清除配置中的旧标星失败: %v", err)
+		} else {
+			log.Printf("⭐ 已将 %d 个标星任务从配置迁移到数据库", len(migrated))
+		}
+	}
+	stars, err := repo.StarredTaskIDs()
+	if err != nil {
+		log.Printf("⚠️ 读取标星任务失败: %v", err)
+		return map[int]bool{}
+	}
+	return stars
+}
+
+// ToggleStar 切换任务的标星状态并返回最新状态（true 表示已标星）。
+// 标星只写入数据库并立即反映到展示结果，不改写任务配置，也不触发重新检查。
+func (s *Service) ToggleStar(taskID int) (bool, error) {
+	if _, ok := s.cfg.GetTask(taskID); !ok {
+		return false, fmt.Errorf("未找到指定任务")
+	}
+	// 数据库写入期间不持有 mu，避免阻塞检查结果处理
+	s.starMu.Lock()
+	defer s.starMu.Unlock()
+	s.mu.RLock()
+	starred := !s.stars[taskID]
+	s.mu.RUnlock()
+	if err := s.repo.SetStarred(taskID, starred); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	if starred {
+		s.stars[taskID] = true
+	} else {
+		delete(s.stars, taskID)
+	}
+	s.mu.Unlock()
+	return starred, nil
+}
+
+// StarredIDs 返回已标星任务 ID 集合的副本。
+func (s *Service) StarredIDs() map[int]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[int]bool, len(s.stars))
+	for id := range s.stars {
+		out[id] = true
+	}
+	return out
+}
@@ -0,0 +1,93 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// checkTCP 对 tcp 类型任务执行端口连通性检查（如 Redis、Postgres、SMTP 中继），
+// 以建立连接的耗时作为响应耗时；状态与颜色沿用 HTTP 检查的规则。
+func (s *Service) checkTCP(task model.MonitorTask, timeout time.Duration) model.MonitorResult {
+	cfg := s.cfg.Get()
+	expanded, expandErr := config.ExpandURL(task.URL, cfg.Vars, task.Vars)
+	if expandErr == nil {
+		task.URL = expanded
+	}
+	res := model.MonitorResult{
+		ID:         task.ID,
+		TaskName:   task.Name,
+		Tenant:     task.Tenant,
+		URL:        task.URL,
+		LastUpdate: time.Now().Format("15:04:05"),
+		Proto:      "TCP",
+	}
+	if expandErr != nil {
+		res.Status, res.StatusColor = "故障", "red"
+		res.Duration = formatDuration(0)
+		res.FailReason = expandErr.Error()
+		return res
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if task.SourceIP != "" {
+		ip := net.ParseIP(task.SourceIP)
+		if ip == nil {
+			res.Status, res.StatusColor = "故障", "red"
+			res.Duration = formatDuration(0)
+			res.FailReason = fmt.Sprintf("源地址不合法: %s", task.SourceIP)
+			return res
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := s.dns.dialContext(dialer)(ctx, "tcp", task.URL)
+	elapsed := time.Since(start)
+	res.Duration = formatDuration(elapsed)
+	res.DurationInt = elapsed.Milliseconds()
+	if err != nil {
+		res.Status, res.StatusColor = "故障", "red"
+		res.FailReason = describeTCPError(timeout, err)
+		var dnsErr *net.DNSError
+		res.DNSFailure = errors.As(err, &dnsErr)
+		return res
+	}
+	_ = conn.Close()
+
+	if task.MaxResponseMS > 0 && !task.InvertStatus && res.DurationInt > task.MaxResponseMS {
+		res.Status, res.StatusColor = "故障", "red"
+		res.FailReason = fmt.Sprintf("响应超时阈值: 建立连接耗时 %dms，超过上限 %dms", res.DurationInt, task.MaxResponseMS)
+		return res
+	}
+	res.IsSuccess = true
+	if res.DurationInt > 800 {
+		res.Status, res.StatusColor = "缓慢", "yellow"
+	} else {
+		res.Status, res.StatusColor = "正常", "green"
+	}
+	return res
+}
+
+// describeTCPError 将建立 TCP 连接的错误转换为便于排查的失败原因。
+func describeTCPError(timeout time.Duration, err error) string {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return "DNS 解析失败: " + dnsErr.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Sprintf("TCP 连接超时: %s 内未建立连接", timeout)
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Timeout() {
+		return fmt.Sprintf("TCP 连接超时: %s 内未建立连接", timeout)
+	}
+	return "TCP 连接失败: " + err.Error()
+}
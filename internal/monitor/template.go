@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// alertMailBody 返回告警邮件正文：配置了 alert_template 时按模板渲染，渲染失败则记录日志并回退默认正文，保证告警不丢。
+func (s *Service) alertMailBody(res model.MonitorResult, failCount int, msg string) string {
+	cfg := s.cfg.Get()
+	if cfg.AlertTemplate == "" {
+		return withBodyPreview(msg, res.BodyPreview)
+	}
+	data := model.AlertTemplateData{
+		TaskName:    res.TaskName,
+		Tenant:      res.Tenant,
+		URL:         res.URL,
+		Status:      res.Status,
+		StatusCode:  res.StatusCode,
+		FailReason:  res.FailReason,
+		FailCount:   failCount,
+		Duration:    res.Duration,
+		Time:        time.Now().Format("2006-01-02 15:04:05"),
+		Message:     msg,
+		BodyPreview: res.BodyPreview,
+	}
+	if cfg.MaskSecrets {
+		data.URL = config.MaskURL(data.URL)
+	}
+	body, err := config.RenderAlertTemplate(cfg.AlertTemplate, data)
+	if err != nil {
+		log.Printf("⚠️ 告警模板渲染失败，使用默认正文: %v", err)
+		return withBodyPreview(msg, res.BodyPreview)
+	}
+	// 模板未引用 .BodyPreview 时仍在末尾附上预览
+	if !strings.Contains(cfg.AlertTemplate, ".BodyPreview") {
+		body = withBodyPreview(body, res.BodyPreview)
+	}
+	return body
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+)
+
+// signWebhook 计算 Webhook 请求签名。
+// 规范串为 "<X-Timestamp>.<原始请求体>"，以共享密钥做 HMAC-SHA256，
+// 结果以 "sha256=<小写十六进制>" 形式放入 X-Signature 请求头。
+// 接收方应使用同样的规范串重新计算并比对签名，同时拒绝时间戳偏差过大的请求以防重放。
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookTemplates 缓存最近一次解析的 Webhook 模板，模板文本未变时直接复用，避免每次发送都重新解析。
+var webhookTemplates struct {
+	mu   sync.Mutex
+	text string
+	tpl  *template.Template
+	err  error
+}
+
+// parsedWebhookTemplate 返回模板文本（非空）对应的解析结果，文本变化时重新解析并替换缓存。
+func parsedWebhookTemplate(text string) (*template.Template, error) {
+	c := &webhookTemplates
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.text != text {
+		c.text = text
+		c.tpl, c.err = config.ParseWebhookTemplate(text)
+	}
+	return c.tpl, c.err
+}
+
+// webhookBody 生成请求体：配置了模板时按模板渲染（便于对接 Slack、Discord 等固定格式的入站 Webhook），
+// 渲染失败则记录日志并回退为默认 JSON 载荷，保证通知不丢。
+func webhookBody(tpl string, payload model.WebhookPayload) ([]byte, error) {
+	if tpl != "" {
+		t, err := parsedWebhookTemplate(tpl)
+		if err == nil {
+			var buf bytes.Buffer
+			if err = t.Execute(&buf, payload); err == nil {
+				return buf.Bytes(), nil
+			}
+		}
+		log.Printf("⚠️ Webhook 模板渲染失败，使用默认载荷: %v", err)
+	}
+	return json.Marshal(payload)
+}
+
+// postWebhook 以 JSON POST 事件到 target，Secret 非空时附带签名头。
+func postWebhook(target, secret, tpl string, payload model.WebhookPayload) error {
+	body, err := webhookBody(tpl, payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "HakimiMonitor/1.0")
+	if secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set("X-Timestamp", ts)
+		req.Header.Set("X-Signature", signWebhook(secret, ts, body))
+	}
+
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook 返回异常状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newWebhookPayload 根据检查结果组装 Webhook 事件。
+func newWebhookPayload(event string, res model.MonitorResult, failCount int, msg string) model.WebhookPayload {
+	return model.WebhookPayload{
+		Event:      event,
+		TaskID:     res.ID,
+		TaskName:   res.TaskName,
+		URL:        res.URL,
+		Status:     res.Status,
+		StatusCode: res.StatusCode,
+		FailCount:  failCount,
+		Message:    msg,
+		Time:       time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"monitor/internal/config"
+	"monitor/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConfigStore 将配置保存在数据库的 config_records 表中，实现 config.Store，
+// 多个监控实例指向同一数据库即可共用一份配置。
+// 每条记录带版本号，保存时按版本做比较并交换，避免多个实例互相覆盖对方的修改。
+type ConfigStore struct {
+	repo *Repo
+	name string
+
+	mu      sync.Mutex
+	seen    bool  // 是否已读到或写入过该记录
+	version int64 // 本实例最近一次读取或写入的版本号
+}
+
+// ConfigStore 返回以 name 为键的数据库配置存储。
+func (r *Repo) ConfigStore(name string) *ConfigStore {
+	return &ConfigStore{repo: r, name: name}
+}
+
+// Load 读取配置内容并记下其版本号；尚无记录时返回 os.ErrNotExist。
+func (s *ConfigStore) Load() ([]byte, error) {
+	var rec model.ConfigRecord
+	err := s.repo.DB.Where("name = ?", s.name).First(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("数据库中没有配置 %q: %w", s.name, os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.seen, s.version = true, rec.Version
+	s.mu.Unlock()
+	return []byte(rec.Data), nil
+}
+
+// Save 仅在数据库中的版本仍是本实例上次读取或写入的版本时覆盖配置，并将版本号加一；
+// 期间其他实例已保存过时返回 config.ErrStoreConflict，由调用方重新加载后再修改。
+func (s *ConfigStore) Save(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+
+	if !s.seen {
+		// 首次写入：记录已由其他实例创建时同样视为冲突
+		rec := model.ConfigRecord{Name: s.name, Data: string(data), Version: 1, UpdatedAt: now}
+		res := s.repo.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&rec)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("%w: 配置 %q 已存在", config.ErrStoreConflict, s.name)
+		}
+		s.seen, s.version = true, 1
+		return nil
+	}
+
+	res := s.repo.DB.Model(&model.ConfigRecord{}).
+		Where("name = ? AND version = ?", s.name, s.version).
+		Updates(map[string]any{"data": string(data), "version": s.version + 1, "updated_at": now})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return fmt.Errorf("%w: 配置 %q 的版本已不是 %d", config.ErrStoreConflict, s.name, s.version)
+	}
+	s.version++
+	return nil
+}
+
+// Changed 报告数据库中的配置是否已被其他实例更新（版本号与本实例所知不同）。
+func (s *ConfigStore) Changed() (bool, error) {
+	var rec model.ConfigRecord
+	err := s.repo.DB.Select("version").Where("name = ?", s.name).First(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.seen || rec.Version != s.version, nil
+}
@@ -3,6 +3,11 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
 	"monitor/internal/model"
 
 	"github.com/glebarez/sqlite"
@@ -23,28 +28,63 @@ func (r *Repo) Close() error {
 	return sqlDB.Close()
 }
 
-// New 初始化 SQLite 数据库连接，并自动迁移 EventLog 和 PerformanceLog 表。
+// New 初始化 SQLite 数据库连接，校验数据库结构版本后自动迁移 EventLog、PerformanceLog、ConfigRecord 与 TaskStar 表。
 func New(path string) (*Repo, error) {
 	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
 	if err != nil {
 		return nil, err
 	}
-	if err := db.AutoMigrate(&model.EventLog{}, &model.PerformanceLog{}); err != nil {
+	if err := migrate(db); err != nil {
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			_ = sqlDB.Close()
+		}
 		return nil, err
 	}
 	return &Repo{DB: db}, nil
 }
 
+// NewWithRetry 在数据库尚未就绪时按固定间隔重试 New，最多尝试 attempts 次，每次失败都会记录日志。
+// 用于容器编排中数据库与监控同时启动的场景；全部失败时返回最后一次的错误。
+func NewWithRetry(path string, attempts int, interval time.Duration) (*Repo, error) {
+	if attempts <= 0 {
+		attempts = 1
+	}
+	var lastErr error
+	for i := 1; i <= attempts; i++ {
+		repo, err := New(path)
+		if err == nil {
+			return repo, nil
+		}
+		lastErr = err
+		if errors.Is(err, ErrSchemaTooNew) {
+			// 版本不兼容不会因等待而好转，直接返回
+			return nil, err
+		}
+		if i < attempts {
+			log.Printf("⏳ 数据库暂不可用 (第 %d/%d 次): %v，%s 后重试", i, attempts, err, interval)
+			time.Sleep(interval)
+		}
+	}
+	return nil, fmt.Errorf("数据库连接失败（已尝试 %d 次）: %w", attempts, lastErr)
+}
+
 // CreateEvent 保存一条事件日志。
 func (r *Repo) CreateEvent(e *model.EventLog) {
 	r.DB.Create(e)
 }
 
-// ResolveDownEvents 将指定任务的所有未解决的宕机事件标记为已解决。
-func (r *Repo) ResolveDownEvents(taskName string) {
-	r.DB.Model(&model.EventLog{}).
+// ResolveDownEvents 将指定任务的所有未解决的宕机事件标记为已解决，返回本次标记的条数。
+func (r *Repo) ResolveDownEvents(taskName string) int64 {
+	return r.DB.Model(&model.EventLog{}).
 		Where("task_name = ? AND type = ? AND is_resolved = ?", taskName, "🔥 宕机警告", false).
-		Update("is_resolved", true)
+		Update("is_resolved", true).RowsAffected
+}
+
+// GetEvent 按 ID 查询单条事件日志。
+func (r *Repo) GetEvent(id uint) (model.EventLog, error) {
+	var e model.EventLog
+	err := r.DB.First(&e, id).Error
+	return e, err
 }
 
 // QueryOpenAlerts 返回当前所有尚未恢复的宕机告警。
@@ -61,6 +101,11 @@ func (r *Repo) CreatePerformance(p *model.PerformanceLog) {
 	r.DB.Create(p)
 }
 
+// CreatePerformanceBatch 批量保存性能日志，每条 INSERT 最多包含 batchSize 行。
+func (r *Repo) CreatePerformanceBatch(logs []model.PerformanceLog, batchSize int) {
+	r.DB.CreateInBatches(logs, batchSize)
+}
+
 // QueryPerformance 查询指定任务的最近 limit 条性能日志，按 ID 降序返回。
 func (r *Repo) QueryPerformance(taskID, limit int) []model.PerformanceLog {
 	var logs []model.PerformanceLog
@@ -75,10 +120,13 @@ func (r *Repo) QueryPerformance(taskID, limit int) []model.PerformanceLog {
 	return logs
 }
 
-// QueryEvents 查询最近的事件日志，limit 指定返回条数，为 0 时返回所有。
-func (r *Repo) QueryEvents(limit int) []model.EventLog {
+// QueryEvents 查询最近的事件日志，limit 指定返回条数，为 0 时返回所有；tenant 非空时只返回该项目的事件。
+func (r *Repo) QueryEvents(limit int, tenant string) []model.EventLog {
 	var logs []model.EventLog
 	q := r.DB.Order("id desc")
+	if tenant != "" {
+		q = q.Where("tenant = ?", tenant)
+	}
 	if limit > 0 {
 		q = q.Limit(limit)
 	}
@@ -86,6 +134,43 @@ func (r *Repo) QueryEvents(limit int) []model.EventLog {
 	return logs
 }
 
+// StreamPerformance 按时间正序分批读取性能日志并逐条回调，避免一次性加载大量历史数据。
+// taskID 为 0 表示全部任务，tenant 非空时只读取该项目；from/to 为零值时不限制对应边界（按入库时间过滤，to 为开区间）。
+func (r *Repo) StreamPerformance(taskID int, tenant string, from, to time.Time, fn func(model.PerformanceLog) error) error {
+	q := r.DB.Model(&model.PerformanceLog{}).Order("id asc")
+	if taskID > 0 {
+		q = q.Where("task_id = ?", taskID)
+	}
+	if tenant != "" {
+		q = q.Where("tenant = ?", tenant)
+	}
+	if !from.IsZero() {
+		q = q.Where("created_at >= ?", from)
+	}
+	if !to.IsZero() {
+		q = q.Where("created_at < ?", to)
+	}
+	var batch []model.PerformanceLog
+	return q.FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, l := range batch {
+			if err := fn(l); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// DeletePerformanceByTask 删除指定任务的全部性能日志，返回删除条数。
+func (r *Repo) DeletePerformanceByTask(taskID int) int64 {
+	return r.DB.Where("task_id = ?", taskID).Delete(&model.PerformanceLog{}).RowsAffected
+}
+
+// DeleteEventsByTask 删除指定任务的全部事件日志，返回删除条数。
+func (r *Repo) DeleteEventsByTask(taskName string) int64 {
+	return r.DB.Where("task_name = ?", taskName).Delete(&model.EventLog{}).RowsAffected
+}
+
 // ClearLogs 清空事件日志表和性能日志表。
 func (r *Repo) ClearLogs() {
 	r.DB.Exec("DELETE FROM event_logs")
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"monitor/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// schemaMigrations 按顺序列出数据库结构的每次变更，新增表或列时在末尾追加一项。
+// 结构本身仍由 AutoMigrate 创建，这里记录版本号，用于启动日志与防止旧程序打开新库。
+var schemaMigrations = []struct {
+	Version int
+	Name    string
+}{
+	{1, "事件日志与性能日志表"},
+	{2, "事件日志增加项目 (tenant)"},
+	{3, "数据库配置存储表 config_records"},
+	{4, "事件日志增加响应头快照 (headers)"},
+	{5, "事件日志增加故障去重键 (dedup_key)"},
+	{6, "任务标星表 task_stars"},
+	{7, "事件日志增加响应体预览 (body_preview)"},
+	{8, "性能日志增加状态码与失败标记 (status_code, failed)"},
+	{9, "配置记录增加版本号 (version)"},
+}
+
+// SchemaVersion 为当前程序支持的数据库结构版本。
+var SchemaVersion = schemaMigrations[len(schemaMigrations)-1].Version
+
+// ErrSchemaTooNew 表示数据库由更新版本的程序创建，当前程序继续写入可能损坏数据。
+var ErrSchemaTooNew = errors.New("数据库结构版本高于当前程序")
+
+// allowSchemaDowngradeEnv 设为 1 时，即使数据库版本更新也继续启动（仅记录警告），用于确认兼容后的紧急回滚。
+const allowSchemaDowngradeEnv = "MONITOR_ALLOW_SCHEMA_DOWNGRADE"
+
+// migrate 检查数据库结构版本后执行 AutoMigrate，并记录本次新应用的结构版本。
+// 数据库版本高于当前程序时拒绝启动，除非显式设置 MONITOR_ALLOW_SCHEMA_DOWNGRADE=1。
+func migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&model.SchemaMigration{}); err != nil {
+		return err
+	}
+	var current int
+	if err := db.Model(&model.SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&current).Error; err != nil {
+		return fmt.Errorf("读取数据库结构版本失败: %v", err)
+	}
+	if current > SchemaVersion {
+		if os.Getenv(allowSchemaDowngradeEnv) != "1" {
+			return fmt.Errorf("%w：数据库为 v%d，当前程序仅支持到 v%d。为避免降级导致数据损坏已拒绝启动，"+
+				"请升级程序或恢复旧备份；确认兼容时可设置 %s=1 强制启动", ErrSchemaTooNew, current, SchemaVersion, allowSchemaDowngradeEnv)
+		}
+		log.Printf("⚠️ 数据库结构版本 v%d 高于当前程序 v%d，已按 %s=1 强制启动", current, SchemaVersion, allowSchemaDowngradeEnv)
+	}
+
+	if err := db.AutoMigrate(&model.EventLog{}, &model.PerformanceLog{}, &model.ConfigRecord{}, &model.TaskStar{}); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, m := range schemaMigrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := db.Create(&model.SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: now}).Error; err != nil {
+			return fmt.Errorf("记录数据库结构版本 v%d 失败: %v", m.Version, err)
+		}
+		log.Printf("🗄️ 数据库结构迁移 v%d: %s", m.Version, m.Name)
+	}
+	if current < SchemaVersion {
+		log.Printf("🗄️ 数据库结构已从 v%d 升级到 v%d", current, SchemaVersion)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"time"
+
+	"monitor/internal/model"
+
+	"gorm.io/gorm/clause"
+)
+
+// StarredTaskIDs 返回所有已标星任务的 ID 集合。
+func (r *Repo) StarredTaskIDs() (map[int]bool, error) {
+	var rows []model.TaskStar
+	if err := r.DB.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int]bool, len(rows))
+	for _, row := range rows {
+		out[row.TaskID] = true
+	}
+	return out, nil
+}
+
+// SetStarred 标星或取消标星指定任务；重复设置为同一状态不会报错。
+func (r *Repo) SetStarred(taskID int, starred bool) error {
+	if !starred {
+		return r.DB.Where("task_id = ?", taskID).Delete(&model.TaskStar{}).Error
+	}
+	return r.DB.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.TaskStar{TaskID: taskID, CreatedAt: time.Now()}).Error
+}
@@ -0,0 +1,348 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookie     = "monitor_session"
+	sessionTTL        = 7 * 24 * time.Hour
+	passwordHashCost  = 12          // bcrypt 代价因子，单次校验约数百毫秒
+	loginFailureDelay = time.Second // 登录失败后的固定延迟，拖慢在线暴力破解
+	loginMaxFailures  = 5           // 同一来源地址在 loginLockout 内允许的失败次数，超出后暂时拒绝登录
+	loginLockout      = 15 * time.Minute
+)
+
+// HashDashboardPassword 生成管理后台登录口令的 bcrypt 哈希（$2a$12$...），
+// 结果填入环境变量 MONITOR_DASHBOARD_PASSWORD_HASH。bcrypt 只使用口令的前 72 字节，更长的口令会被拒绝。
+func HashDashboardPassword(password string) (string, error) {
+	if password == "" {
+		return "", errors.New("口令不能为空")
+	}
+	sum, err := bcrypt.GenerateFromPassword([]byte(password), passwordHashCost)
+	if err != nil {
+		return "", fmt.Errorf("生成口令哈希失败: %w", err)
+	}
+	return string(sum), nil
+}
+
+// passwordHash 是校验过格式的 bcrypt 口令哈希。
+type passwordHash []byte
+
+func parsePasswordHash(s string) (passwordHash, error) {
+	hash := []byte(strings.TrimSpace(s))
+	if _, err := bcrypt.Cost(hash); err != nil {
+		return nil, fmt.Errorf("应为 bcrypt 哈希（以 $2a$ 或 $2b$ 开头，可用 hash-password 子命令生成）: %v", err)
+	}
+	return hash, nil
+}
+
+func (p passwordHash) verify(password string) bool {
+	return bcrypt.CompareHashAndPassword(p, []byte(password)) == nil
+}
+
+// loginLimiter 按来源地址统计登录失败次数：loginLockout 内失败满 loginMaxFailures 次后，
+// 该地址在窗口结束前的登录请求直接拒绝，不再校验口令。并发提交同样计数，弥补固定延迟无法限制并行请求的不足。
+type loginLimiter struct {
+	mu    sync.Mutex
+	fails map[string]loginFailures
+}
+
+type loginFailures struct {
+	count int
+	since time.Time // 本窗口内首次失败的时间
+}
+
+// blocked 返回该地址是否已被暂时禁止登录，以及还需等待的时长。
+func (l *loginLimiter) blocked(ip string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.fails[ip]
+	if !ok || f.count < loginMaxFailures {
+		return 0, false
+	}
+	wait := f.since.Add(loginLockout).Sub(now)
+	return wait, wait > 0
+}
+
+// fail 记录一次失败，并顺带清理已过窗口的记录。
+func (l *loginLimiter) fail(ip string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fails == nil {
+		l.fails = make(map[string]loginFailures)
+	}
+	for k, f := range l.fails {
+		if now.Sub(f.since) >= loginLockout {
+			delete(l.fails, k)
+		}
+	}
+	f := l.fails[ip]
+	if f.count == 0 {
+		f.since = now
+	}
+	f.count++
+	l.fails[ip] = f
+}
+
+// reset 在登录成功后清除该地址的失败记录。
+func (l *loginLimiter) reset(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.fails, ip)
+}
+
+// remoteIP 返回请求的来源地址（不含端口）。不信任 X-Forwarded-For，反向代理后的请求共用代理地址的配额。
+func remoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// sessionAuth 实现管理后台的口令登录。会话为无状态的签名 Cookie（过期时间.随机串.HMAC），
+// 退出登录时将随机串记入吊销表直至过期，使已退出的 Cookie 即使被留存也无法再用。
+type sessionAuth struct {
+	hash    passwordHash
+	key     []byte
+	limiter loginLimiter
+
+	mu      sync.Mutex
+	revoked map[string]time.Time // 已退出的会话随机串 -> 原过期时间
+}
+
+// newSessionAuth 从环境变量 MONITOR_DASHBOARD_PASSWORD_HASH 读取口令哈希；未配置时返回 nil，即不启用登录。
+// 签名密钥由 MONITOR_SECRET_KEY 与口令哈希派生，重启后会话仍有效、修改口令后旧会话全部失效；
+// 未设置 MONITOR_SECRET_KEY 时使用进程内随机密钥，重启后需重新登录。
+func newSessionAuth() *sessionAuth {
+	raw := os.Getenv("MONITOR_DASHBOARD_PASSWORD_HASH")
+	if raw == "" {
+		log.Printf("⚠️ 未设置 MONITOR_DASHBOARD_PASSWORD_HASH，管理后台无需登录即可访问")
+		return nil
+	}
+	hash, err := parsePasswordHash(raw)
+	if err != nil {
+		// 配置了却无法解析时不能静默放行，否则后台会在运维以为已加锁的情况下对外开放
+		log.Fatalf("❌ MONITOR_DASHBOARD_PASSWORD_HASH 无效: %v", err)
+	}
+
+	var key []byte
+	if secret := os.Getenv("MONITOR_SECRET_KEY"); secret != "" {
+		sum := sha256.Sum256([]byte("dashboard-session\x00" + secret + "\x00" + raw))
+		key = sum[:]
+	} else {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("❌ 生成会话密钥失败: %v", err)
+		}
+	}
+	log.Printf("🔐 管理后台已启用登录")
+	return &sessionAuth{hash: hash, key: key, revoked: make(map[string]time.Time)}
+}
+
+func (a *sessionAuth) sign(payload string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// issue 生成新的会话 Cookie 值。
+func (a *sessionAuth) issue(now time.Time) (string, time.Time, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", time.Time{}, err
+	}
+	expires := now.Add(sessionTTL)
+	payload := strconv.FormatInt(expires.Unix(), 10) + "." + hex.EncodeToString(nonce)
+	return payload + "." + a.sign(payload), expires, nil
+}
+
+// parse 校验 Cookie 值的签名与有效期，返回会话随机串及过期时间。
+func (a *sessionAuth) parse(value string, now time.Time) (nonce string, expires time.Time, ok bool) {
+	i := strings.LastIndexByte(value, '.')
+	if i < 0 {
+		return "", time.Time{}, false
+	}
+	payload, sig := value[:i], value[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(a.sign(payload))) {
+		return "", time.Time{}, false
+	}
+	exp, nonce, found := strings.Cut(payload, ".")
+	if !found {
+		return "", time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	expires = time.Unix(unix, 0)
+	if !now.Before(expires) {
+		return "", time.Time{}, false
+	}
+	a.mu.Lock()
+	_, revoked := a.revoked[nonce]
+	a.mu.Unlock()
+	return nonce, expires, !revoked
+}
+
+// valid 判断请求是否携带有效会话。
+func (a *sessionAuth) valid(r *http.Request) bool {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return false
+	}
+	_, _, ok := a.parse(c.Value, time.Now())
+	return ok
+}
+
+// revoke 吊销请求携带的会话，并顺带清理已自然过期的吊销记录。
+func (a *sessionAuth) revoke(r *http.Request) {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	nonce, expires, ok := a.parse(c.Value, now)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for n, exp := range a.revoked {
+		if !now.Before(exp) {
+			delete(a.revoked, n)
+		}
+	}
+	a.revoked[nonce] = expires
+}
+
+// setSessionCookie 写入会话 Cookie；SameSite=Lax 使跨站表单提交不携带会话，兼作 CSRF 防护。
+func setSessionCookie(w http.ResponseWriter, r *http.Request, value string, expires time.Time) {
+	maxAge := int(time.Until(expires).Seconds())
+	if value == "" {
+		maxAge = -1
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https"),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// loginExempt 列出无需登录即可访问的路径：登录/退出页面本身与静态资源。
+func loginExempt(path string) bool {
+	return path == "/login" || path == "/logout" || path == "/favicon.ico" || strings.HasPrefix(path, "/assets/")
+}
+
+// requireLogin 在启用登录时拦截未登录请求。携带有效管理令牌（见 requireAdmin）的请求同样放行，
+// 供 Prometheus 抓取、编排系统调用等无法走登录页的自动化场景使用。
+// 页面请求重定向到登录页，接口请求返回 401 并带 X-Login-Required 头，前端据此跳转。
+func (h *Handler) requireLogin(next http.Handler) http.Handler {
+	if h.auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if loginExempt(r.URL.Path) || h.auth.valid(r) || adminTokenValid(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+		w.Header().Set("X-Login-Required", "1")
+		http.Error(w, "未登录或会话已过期", http.StatusUnauthorized)
+	})
+}
+
+var loginTpl = template.Must(template.ParseFS(templateFS, "templates/login.html"))
+
+// safeNext 只接受站内相对路径作为登录后的跳转目标，防止开放重定向。
+func safeNext(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
+
+// loginHandler 展示登录页（GET）并校验口令（POST），成功后写入会话 Cookie 并跳回原页面。
+func (h *Handler) loginHandler(w http.ResponseWriter, r *http.Request) {
+	if h.auth == nil {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	render := func(status int, msg, next string) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		if err := loginTpl.Execute(w, struct{ Error, Next string }{msg, next}); err != nil {
+			log.Printf("⚠️ 渲染登录页失败: %v", err)
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		render(http.StatusOK, "", safeNext(r.URL.Query().Get("next")))
+	case http.MethodPost:
+		next := safeNext(r.FormValue("next"))
+		ip := remoteIP(r)
+		if wait, blocked := h.auth.limiter.blocked(ip, time.Now()); blocked {
+			minutes := int(wait.Minutes()) + 1
+			w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+			render(http.StatusTooManyRequests, fmt.Sprintf("登录失败次数过多，请 %d 分钟后再试", minutes), next)
+			return
+		}
+		if !h.auth.hash.verify(r.FormValue("password")) {
+			h.auth.limiter.fail(ip, time.Now())
+			log.Printf("🔐 管理后台登录失败: %s", r.RemoteAddr)
+			time.Sleep(loginFailureDelay)
+			render(http.StatusUnauthorized, "口令错误", next)
+			return
+		}
+		h.auth.limiter.reset(ip)
+		value, expires, err := h.auth.issue(time.Now())
+		if err != nil {
+			http.Error(w, "创建会话失败: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		setSessionCookie(w, r, value, expires)
+		log.Printf("🔐 管理后台登录成功: %s", r.RemoteAddr)
+		http.Redirect(w, r, next, http.StatusSeeOther)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// logoutHandler 吊销当前会话并清除 Cookie，随后回到登录页。
+func (h *Handler) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.auth != nil {
+		h.auth.revoke(r)
+		setSessionCookie(w, r, "", time.Unix(0, 0))
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupDir 是手动与自动备份共用的目录。
+const backupDir = "backup"
+
+// backupTimeLayout 是备份文件名前缀的时间格式，同一次备份的文件共享该前缀。
+const backupTimeLayout = "20060102-150405"
+
+// writeBackup 将当前配置文件与 monitor.db 复制到 backup 目录，返回生成的文件路径；compress 为 true 时保存为 .gz。
+func (h *Handler) writeBackup(compress bool) ([]string, error) {
+	ts := time.Now().Format(backupTimeLayout)
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// 配置保存在数据库中时没有独立的配置文件，备份 monitor.db 即已包含配置
+	files := []string{"monitor.db"}
+	if p := h.cfg.Path(); p != "" {
+		files = []string{p, "monitor.db"}
+	}
+	copied := []string{}
+	for _, f := range files {
+		dst := filepath.Join(backupDir, fmt.Sprintf("%s-%s", ts, filepath.Base(f)))
+		copyFn := copyFile
+		if compress {
+			dst += ".gz"
+			copyFn = gzipFile
+		}
+		if err := copyFn(f, dst); err != nil {
+			return copied, err
+		}
+		copied = append(copied, dst)
+	}
+	return copied, nil
+}
+
+// pruneBackups 按备份批次（文件名时间前缀）保留最新的 keep 批，删除更早的备份文件；keep<=0 时不清理。
+func pruneBackups(keep int) (removed int) {
+	if keep <= 0 {
+		return 0
+	}
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return 0
+	}
+	batches := map[string][]string{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || len(name) <= len(backupTimeLayout) || name[len(backupTimeLayout)] != '-' {
+			continue
+		}
+		prefix := name[:len(backupTimeLayout)]
+		if _, err := time.Parse(backupTimeLayout, prefix); err != nil {
+			continue
+		}
+		batches[prefix] = append(batches[prefix], name)
+	}
+	stamps := make([]string, 0, len(batches))
+	for ts := range batches {
+		stamps = append(stamps, ts)
+	}
+	// 时间前缀按字典序即时间序，倒序后前 keep 个为最新批次
+	sort.Sort(sort.Reverse(sort.StringSlice(stamps)))
+	for _, ts := range stamps[min(keep, len(stamps)):] {
+		for _, name := range batches[ts] {
+			if err := os.Remove(filepath.Join(backupDir, name)); err == nil {
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+// RunAutoBackup 按 backup_interval_hours 定期备份配置与数据库，并按 backup_keep 清理旧备份，直到 ctx 结束。
+// 每轮都重新读取配置，设置页修改间隔后无需重启；间隔为 0 时仅定期检查配置是否开启。
+func (h *Handler) RunAutoBackup(ctx context.Context) {
+	const idleCheck = time.Minute
+	last := time.Now()
+	for {
+		wait := idleCheck
+		cfg := h.cfg.Get()
+		if cfg.BackupIntervalHours > 0 {
+			next := last.Add(time.Duration(cfg.BackupIntervalHours) * time.Hour)
+			if wait = time.Until(next); wait <= 0 {
+				files, err := h.writeBackup(false)
+				if err != nil {
+					log.Printf("❌ 自动备份失败: %v", err)
+				} else {
+					removed := pruneBackups(cfg.BackupKeep)
+					log.Printf("🛟 自动备份完成: %s（清理旧备份 %d 个文件）", strings.Join(files, ", "), removed)
+				}
+				last = time.Now()
+				continue
+			}
+			wait = min(wait, idleCheck)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
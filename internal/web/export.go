@@ -0,0 +1,85 @@
+package web
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// wantGzip 判断请求是否要求压缩下载（gzip=1 或 gzip=true）。
+func wantGzip(r *http.Request) bool {
+	v := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("gzip")))
+	return v == "1" || v == "true"
+}
+
+// csvDownload 是 CSV 下载的写入目标，按需透明地做 gzip 压缩。
+type csvDownload struct {
+	w       io.Writer
+	gz      *gzip.Writer
+	flusher http.Flusher
+}
+
+// startCSVDownload 设置 CSV 附件响应头并写入 UTF-8 BOM（使 Excel 识别中文）。
+// 请求带 gzip=1 时以 application/gzip 下载 <filename>.gz，BOM 位于解压后内容的开头。
+func startCSVDownload(w http.ResponseWriter, r *http.Request, filename string) *csvDownload {
+	d := &csvDownload{w: w}
+	d.flusher, _ = w.(http.Flusher)
+	if wantGzip(r) {
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename+".gz")
+		w.Header().Set("Content-Type", "application/gzip")
+		d.gz = gzip.NewWriter(w)
+		d.w = d.gz
+	} else {
+		w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+		w.Header().Set("Content-Type", "text/csv")
+	}
+	_, _ = d.Write([]byte("\xEF\xBB\xBF"))
+	return d
+}
+
+func (d *csvDownload) Write(p []byte) (int, error) {
+	return d.w.Write(p)
+}
+
+// Flush 把已写入的数据（含压缩缓冲）推送给客户端。
+func (d *csvDownload) Flush() {
+	if d.gz != nil {
+		_ = d.gz.Flush()
+	}
+	if d.flusher != nil {
+		d.flusher.Flush()
+	}
+}
+
+// Close 结束下载；压缩模式下写出 gzip 尾部。
+func (d *csvDownload) Close() {
+	if d.gz != nil {
+		_ = d.gz.Close()
+	}
+}
+
+// gzipFile 将 src 压缩写入 dst（覆盖目标）。
+func gzipFile(src, dst string) error {
+	srcF, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcF.Close()
+
+	dstF, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer dstF.Close()
+
+	gz := gzip.NewWriter(dstF)
+	if _, err := io.Copy(gz, srcF); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return dstF.Sync()
+}
@@ -0,0 +1,163 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// grafanaMaxRange 限制单次查询的时间跨度，避免一次请求扫描过多历史数据。
+const grafanaMaxRange = 93 * 24 * time.Hour
+
+// grafanaQueryRequest 对应 Grafana SimpleJSON / Infinity 数据源的 /query 请求体（仅取用到的字段）。
+type grafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	Targets []struct {
+		Target string `json:"target"`
+		Type   string `json:"type"`
+	} `json:"targets"`
+	MaxDataPoints int `json:"maxDataPoints"`
+}
+
+// grafanaSeries 是 /query 返回的一条时间序列，datapoints 为 [值, Unix 毫秒时间戳]。
+type grafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// grafanaRootHandler 响应 Grafana 数据源的连通性测试（GET /grafana/）。
+func (h *Handler) grafanaRootHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/grafana" && r.URL.Path != "/grafana/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("OK"))
+}
+
+// grafanaSearchHandler 返回可查询的任务名称列表（不含已归档任务），
+// 请求体中的 target 非空时按名称包含关系（不区分大小写）过滤。
+func (h *Handler) grafanaSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		Target string `json:"target"`
+	}
+	if r.Method == http.MethodPost && r.ContentLength != 0 {
+		_ = json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req)
+	}
+	filter := strings.ToLower(strings.TrimSpace(req.Target))
+
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, t := range h.cfg.Get().Tasks {
+		if t.Archived || seen[t.Name] {
+			continue
+		}
+		if filter != "" && !strings.Contains(strings.ToLower(t.Name), filter) {
+			continue
+		}
+		seen[t.Name] = true
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	writeJSON(w, r, names)
+}
+
+// grafanaQueryHandler 按请求的时间范围从性能日志中取出各任务的响应耗时序列。
+// target 为任务名称（与 /grafana/search 返回值一致），未知任务返回空序列；
+// 点数超过 maxDataPoints 时按等宽时间桶取平均值降采样。
+func (h *Handler) grafanaQueryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req grafanaQueryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		http.Error(w, "请求体格式错误: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	from, to := req.Range.From, req.Range.To
+	if from.IsZero() || to.IsZero() || !to.After(from) {
+		http.Error(w, "range.from / range.to 必须为有效的 RFC3339 时间且 from 早于 to", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) > grafanaMaxRange {
+		http.Error(w, "查询时间跨度不能超过 93 天", http.StatusBadRequest)
+		return
+	}
+
+	ids := make(map[string]int)
+	for _, t := range h.cfg.Get().Tasks {
+		if _, ok := ids[t.Name]; !ok {
+			ids[t.Name] = t.ID
+		}
+	}
+
+	out := make([]grafanaSeries, 0, len(req.Targets))
+	for _, target := range req.Targets {
+		if target.Target == "" {
+			continue
+		}
+		series := grafanaSeries{Target: target.Target, Datapoints: [][2]float64{}}
+		if id, ok := ids[target.Target]; ok {
+			err := h.repo.StreamPerformance(id, "", from, to, func(l model.PerformanceLog) error {
+				// 失败样本的耗时多为超时时长，混入后会拉高降采样均值
+				if l.Failed {
+					return nil
+				}
+				series.Datapoints = append(series.Datapoints, [2]float64{float64(l.ResponseTime), float64(l.CreatedAt.UnixMilli())})
+				return nil
+			})
+			if err != nil {
+				log.Printf("⚠️ Grafana 查询任务 %q 失败: %v", target.Target, err)
+			}
+			series.Datapoints = downsampleDatapoints(series.Datapoints, from, to, req.MaxDataPoints)
+		}
+		out = append(out, series)
+	}
+	writeJSON(w, r, out)
+}
+
+// grafanaAnnotationsHandler 满足数据源协议的 /annotations 接口，目前不提供注释数据。
+func (h *Handler) grafanaAnnotationsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, []any{})
+}
+
+// downsampleDatapoints 在点数超过 maxPoints 时把 [from, to) 等分为 maxPoints 个时间桶，
+// 每个非空桶输出一个点（值取平均，时间取桶内首个点）；maxPoints<=0 时原样返回。
+func downsampleDatapoints(points [][2]float64, from, to time.Time, maxPoints int) [][2]float64 {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+	start := float64(from.UnixMilli())
+	width := float64(to.UnixMilli()-from.UnixMilli()) / float64(maxPoints)
+	out := make([][2]float64, 0, maxPoints)
+	bucket, sum, count := -1, 0.0, 0
+	var ts float64
+	for _, p := range points {
+		b := int((p[1] - start) / width)
+		if b != bucket {
+			if count > 0 {
+				out = append(out, [2]float64{sum / float64(count), ts})
+			}
+			bucket, sum, count, ts = b, 0, 0, p[1]
+		}
+		sum += p[0]
+		count++
+	}
+	if count > 0 {
+		out = append(out, [2]float64{sum / float64(count), ts})
+	}
+	return out
+}
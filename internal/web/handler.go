@@ -2,13 +2,18 @@
 package web
 
 import (
+	"compress/gzip"
+	"crypto/subtle"
 	"embed"
 	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"io/fs"
+	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -25,7 +30,7 @@ import (
 	"monitor/internal/repository"
 )
 
-//go:embed templates/index.html templates/assets/*
+//go:embed templates/index.html templates/login.html templates/assets/*
 var templateFS embed.FS
 
 // Handler 聚合了配置、仓储、监控服务以及模板，处理所有 HTTP 请求。
@@ -37,39 +42,79 @@ type Handler struct {
 	start  time.Time
 	tpl    *template.Template
 	assets http.Handler
+	auth   *sessionAuth // 管理后台登录，未配置口令时为 nil
 }
 
 // New 创建 Web 处理器实例。
 func New(cfg *config.Manager, repo *repository.Repo, mon *monitor.Service, ai *analysis.Service, start time.Time) *Handler {
 	// 🔥 使用 ParseFS 从内存里读取网页
-	tpl, err := template.ParseFS(templateFS, "templates/index.html")
+	// 国际化域名以 punycode 保存，页面展示时还原为 Unicode
+	funcs := template.FuncMap{"displayURL": config.DisplayURL}
+	tpl, err := template.New("index.html").Funcs(funcs).ParseFS(templateFS, "templates/index.html")
 	if err != nil {
 		panic("解析内置模板失败: " + err.Error())
 	}
+	// 配置了外部模板时优先使用，解析失败则回退到内置模板，便于不重新编译即可定制看板
+	if path := cfg.Get().TemplatePath; path != "" {
+		custom, err := template.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
+		if err != nil {
+			log.Printf("⚠️ 外部页面模板 %s 解析失败，使用内置模板: %v", path, err)
+		} else {
+			tpl = custom
+			log.Printf("🎨 使用外部页面模板: %s", path)
+		}
+	} else {
+		log.Printf("🎨 使用内置页面模板")
+	}
 	assetFS, err := fs.Sub(templateFS, "templates/assets")
 	if err != nil {
 		panic("解析内置静态资源失败: " + err.Error())
 	}
 	assets := http.StripPrefix("/assets/", http.FileServer(http.FS(assetFS)))
-	return &Handler{cfg: cfg, repo: repo, mon: mon, ai: ai, tpl: tpl, start: start, assets: assets}
+	return &Handler{cfg: cfg, repo: repo, mon: mon, ai: ai, tpl: tpl, start: start, assets: assets, auth: newSessionAuth()}
 }
 
-// Register 将路由及其对应的处理函数注册到 ServeMux。
-func (h *Handler) Register(mux *http.ServeMux) {
+// Register 将路由及其对应的处理函数注册到 ServeMux。启用登录时，除登录页与静态资源外的路由都经过 requireLogin。
+func (h *Handler) Register(root *http.ServeMux) {
+	mux := http.NewServeMux()
+	root.Handle("/", h.requireLogin(mux))
 	mux.Handle("/assets/", h.assets)
+	mux.HandleFunc("/login", h.loginHandler)
+	mux.HandleFunc("/logout", h.logoutHandler)
 	mux.HandleFunc("/", h.webHandler)
 	mux.HandleFunc("/api/chart", h.chartDataHandler)
 	mux.HandleFunc("/api/performance/logs", h.performanceLogsHandler)
+	mux.HandleFunc("/api/sparkline", h.sparklineHandler)
 	mux.HandleFunc("/api/results", h.resultsHandler)
+	mux.HandleFunc("/api/tree", h.statusTreeHandler)
 	mux.HandleFunc("/api/analysis/summary", h.analysisSummaryHandler)
 	mux.HandleFunc("/api/analysis/detail", h.analysisDetailHandler)
 	mux.HandleFunc("/api/task/add", h.addTaskHandler)
 	mux.HandleFunc("/api/task/update", h.updateTaskHandler)
 	mux.HandleFunc("/api/task/delete", h.deleteTaskHandler)
+	mux.HandleFunc("/api/task/archive", h.archiveTaskHandler)
+	mux.HandleFunc("/api/task/pause", h.pauseTaskHandler)
+	mux.HandleFunc("/api/task/clone", h.cloneTaskHandler)
+	mux.HandleFunc("/api/task/check", h.checkTaskHandler)
+	mux.HandleFunc("/api/task/check-batch", h.checkBatchHandler)
+	mux.HandleFunc("/api/task/inspect", h.inspectTaskHandler)
+	mux.HandleFunc("/api/task/test-alert", h.testTaskAlertHandler)
+	mux.HandleFunc("/api/incident/resolve", h.incidentResolveHandler)
+	mux.HandleFunc("/api/event", h.eventDetailHandler)
+	mux.HandleFunc("/api/alert/preview", h.alertPreviewHandler)
+	mux.HandleFunc("/api/provision", h.provisionHandler)
+	mux.HandleFunc("/api/discovery/sync", h.discoverySyncHandler)
 	mux.HandleFunc("/api/settings/update", h.updateSettingsHandler)
+	mux.HandleFunc("/api/config/effective", h.effectiveConfigHandler)
 	mux.HandleFunc("/api/logs/clear", h.clearLogsHandler)
 	mux.HandleFunc("/api/sys/stats", h.sysStatsHandler)
+	mux.HandleFunc("/metrics", h.metricsHandler)
+	mux.HandleFunc("/grafana/", h.grafanaRootHandler)
+	mux.HandleFunc("/grafana/search", h.grafanaSearchHandler)
+	mux.HandleFunc("/grafana/query", h.grafanaQueryHandler)
+	mux.HandleFunc("/grafana/annotations", h.grafanaAnnotationsHandler)
 	mux.HandleFunc("/api/logs/export", h.exportCsvHandler)
+	mux.HandleFunc("/api/perf/export", h.exportPerformanceCsvHandler)
 	mux.HandleFunc("/api/task/star", h.toggleStarHandler)
 	mux.HandleFunc("/api/backup", h.backupHandler)
 	mux.HandleFunc("/api/reset", h.resetHandler)
@@ -82,7 +127,7 @@ func (h *Handler) resultsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res := h.mon.Results()
+	res := filterResultsByTenant(h.maskResults(h.resultsWithPending()), r.URL.Query().Get("tenant"))
 
 	// 保持与页面排序规则一致：标星优先，其次按 ID 升序
 	sort.Slice(res, func(i, j int) bool {
@@ -92,8 +137,18 @@ func (h *Handler) resultsHandler(w http.ResponseWriter, r *http.Request) {
 		return res[i].ID < res[j].ID
 	})
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(res)
+	writeJSON(w, r, res)
+}
+
+// statusTreeHandler 返回按项目与依赖关系分层的健康汇总，供下钻式状态页使用。
+// 可选参数 mode=worst|weighted 临时覆盖配置的汇总规则，tenant 限定项目。
+func (h *Handler) statusTreeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	writeJSON(w, r, h.mon.StatusTree(q.Get("mode"), strings.TrimSpace(q.Get("tenant"))))
 }
 
 func (h *Handler) analysisSummaryHandler(w http.ResponseWriter, r *http.Request) {
@@ -104,8 +159,7 @@ func (h *Handler) analysisSummaryHandler(w http.ResponseWriter, r *http.Request)
 
 	data := h.ai.Get(r.URL.Query().Get("force") == "1")
 	data.TaskBreakdown = nil
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(data)
+	writeJSON(w, r, data)
 }
 
 func (h *Handler) analysisDetailHandler(w http.ResponseWriter, r *http.Request) {
@@ -115,8 +169,117 @@ func (h *Handler) analysisDetailHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	data := h.ai.Get(r.URL.Query().Get("force") == "1")
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(data)
+	writeJSON(w, r, data)
+}
+
+// redactedConfig 返回当前生效配置的副本，并清空所有敏感字段，供页面和接口展示。
+func (h *Handler) redactedConfig() model.Config {
+	cfg := h.cfg.Get()
+	cfg.SMTP.Password = ""
+	cfg.Analysis.LLM.APIKey = ""
+	cfg.Webhook.Secret = ""
+	tasks := make([]model.MonitorTask, len(cfg.Tasks))
+	for i, t := range cfg.Tasks {
+		if cfg.MaskSecrets {
+			t.URL = config.MaskURL(t.URL)
+		}
+		t = config.MaskTaskSecrets(t)
+		tasks[i] = t
+	}
+	cfg.Tasks = tasks
+	return cfg
+}
+
+// maskTaskSecrets 返回隐去请求头值与 Basic 认证密码的任务列表副本。
+func maskTaskSecrets(tasks []model.MonitorTask) []model.MonitorTask {
+	out := make([]model.MonitorTask, len(tasks))
+	for i, t := range tasks {
+		t = config.MaskTaskSecrets(t)
+		out[i] = t
+	}
+	return out
+}
+
+// resultsWithPending 返回最新检查结果，并为已配置但尚未完成首次检查的任务补上灰色“待检测”条目，
+// 避免新添加的任务在首轮检查结束前不显示或显示过期内容。
+func (h *Handler) resultsWithPending() []model.MonitorResult {
+	res := h.mon.Results()
+	stars := h.mon.StarredIDs()
+	seen := make(map[int]bool, len(res))
+	for _, r := range res {
+		seen[r.ID] = true
+	}
+	for _, t := range h.cfg.Get().Tasks {
+		if t.Archived || seen[t.ID] {
+			continue
+		}
+		status := "待检测"
+		if t.Manual {
+			status = "待手动检查"
+		}
+		res = append(res, model.MonitorResult{
+			ID:          t.ID,
+			TaskName:    t.Name,
+			Tenant:      t.Tenant,
+			URL:         t.URL,
+			Status:      status,
+			StatusColor: "gray",
+			Duration:    "-",
+			HistoryDots: []string{},
+			Starred:     stars[t.ID],
+		})
+	}
+	return res
+}
+
+// filterResultsByTenant 只保留指定项目的结果；tenant 为空时原样返回全部结果。
+func filterResultsByTenant(res []model.MonitorResult, tenant string) []model.MonitorResult {
+	tenant = strings.TrimSpace(tenant)
+	if tenant == "" {
+		return res
+	}
+	out := res[:0]
+	for _, r := range res {
+		if r.Tenant == tenant {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// tenantsOf 返回配置中出现过的项目名（去重、排序），用于看板的项目筛选。
+func tenantsOf(tasks []model.MonitorTask) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, t := range tasks {
+		if t.Tenant != "" && !seen[t.Tenant] {
+			seen[t.Tenant] = true
+			out = append(out, t.Tenant)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// maskResults 在开启 mask_secrets 时将结果中的任务地址替换为脱敏形式。
+func (h *Handler) maskResults(res []model.MonitorResult) []model.MonitorResult {
+	if !h.cfg.Get().MaskSecrets {
+		return res
+	}
+	for i := range res {
+		res[i].URL = config.MaskURL(res[i].URL)
+	}
+	return res
+}
+
+// effectiveConfigHandler 返回监控当前实际使用的配置（已套用默认值、敏感字段已清空），
+// 用于排查落盘配置与运行时生效值不一致的问题。
+func (h *Handler) effectiveConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, r, h.redactedConfig())
 }
 
 // webHandler 渲染主页面，传入当前监控结果、最近事件日志和配置（隐藏密码）。
@@ -124,12 +287,11 @@ func (h *Handler) webHandler(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path == "/favicon.ico" {
 		return
 	}
-	cfg := h.cfg.Get()
-	cfg.SMTP.Password = ""
-	cfg.Analysis.LLM.APIKey = ""
+	cfg := h.redactedConfig()
+	tenant := strings.TrimSpace(r.URL.Query().Get("tenant"))
 
 	// 🔥 获取结果并进行智能排序
-	results := h.mon.Results()
+	results := filterResultsByTenant(h.maskResults(h.resultsWithPending()), tenant)
 	sort.Slice(results, func(i, j int) bool {
 		// 规则1：如果标星状态不同，标星(true)的排在前面
 		if results[i].Starred != results[j].Starred {
@@ -144,16 +306,25 @@ func (h *Handler) webHandler(w http.ResponseWriter, r *http.Request) {
 		Logs     []model.EventLog
 		Config   model.Config
 		Analysis model.StabilityAnalysis
+		Tenant   string   // 当前筛选的项目，为空表示全部
+		Tenants  []string // 配置中出现过的全部项目
+		Auth     bool     // 是否启用了登录，决定是否显示退出按钮
 	}{
 		Results:  results, // 🔥 用排序后的结果替换
-		Logs:     h.repo.QueryEvents(50),
+		Logs:     h.repo.QueryEvents(50, tenant),
 		Config:   cfg,
 		Analysis: h.ai.Get(false),
+		Tenant:   tenant,
+		Tenants:  tenantsOf(cfg.Tasks),
+		Auth:     h.auth != nil,
+	}
+	if err := h.tpl.Execute(w, data); err != nil {
+		log.Printf("⚠️ 渲染页面模板失败: %v", err)
 	}
-	_ = h.tpl.Execute(w, data)
 }
 
 // addTaskHandler 处理添加监控任务的请求。
+// 请求体除 name/url 外可携带任务的扩展选项字段。
 // 支持 force 参数跳过连通性校验，添加成功后立即触发一次监控检查。
 func (h *Handler) addTaskHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -162,29 +333,39 @@ func (h *Handler) addTaskHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		Name  string `json:"name"`
-		URL   string `json:"url"`
-		Force bool   `json:"force"` // 是否强制添加（跳过连通性校验）
+		model.MonitorTask
+		Force bool `json:"force"` // 是否强制添加（跳过连通性校验）
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "请求体解析失败: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	name, normalizedURL, err := config.NormalizeAndValidateTaskInput(req.Name, req.URL)
+	globalVars := h.cfg.Get().Vars
+	name, normalizedURL, err := config.NormalizeAndValidateTaskInput(req.Name, req.URL, req.Type, globalVars, req.Vars)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// 扩展选项（如客户端证书）先于连通性校验检查，错误能直接指出配置问题
+	req.URL = normalizedURL
+	if err := config.ValidateTaskOptions(&req.MonitorTask, globalVars); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// 若非强制模式，进行连通性校验
 	if !req.Force {
-		if err := probeURL(normalizedURL); err != nil {
+		// 模板地址已在规范化时校验可展开，这里对展开后的实际地址做连通性校验
+		target, _ := config.ExpandURL(normalizedURL, globalVars, req.Vars)
+		if err := probeTarget(target, req.Type); err != nil {
 			http.Error(w, "连通性校验失败: "+err.Error()+"（可选择强制添加）", http.StatusUnprocessableEntity)
 			return
 		}
 	}
 
-	_, err = h.cfg.AddTask(name, normalizedURL)
+	task := req.MonitorTask
+	task.Name, task.URL = name, normalizedURL
+	_, err = h.cfg.AddTask(task)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -196,41 +377,81 @@ func (h *Handler) addTaskHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // updateTaskHandler 处理监控任务修改请求，支持强制跳过连通性校验。
+// 请求体以现有任务为底稿解码，未携带的字段保持原值，避免只改名称时清空扩展选项。
 func (h *Handler) updateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var req struct {
-		ID    int    `json:"id"`
-		Name  string `json:"name"`
-		URL   string `json:"url"`
-		Force bool   `json:"force"`
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "请求体读取失败: "+err.Error(), http.StatusBadRequest)
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	var idReq struct {
+		ID      int             `json:"id"`
+		Headers json.RawMessage `json:"headers"`
+	}
+	if err := json.Unmarshal(body, &idReq); err != nil {
 		http.Error(w, "请求体解析失败: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	if req.ID <= 0 {
+	if idReq.ID <= 0 {
 		http.Error(w, "invalid id", http.StatusBadRequest)
 		return
 	}
+	existing, ok := h.cfg.GetTask(idReq.ID)
+	if !ok {
+		http.Error(w, "未找到指定任务", http.StatusBadRequest)
+		return
+	}
 
-	name, normalizedURL, err := config.NormalizeAndValidateTaskInput(req.Name, req.URL)
+	req := struct {
+		model.MonitorTask
+		Force bool `json:"force"`
+	}{MonitorTask: existing}
+	// 携带 headers 时整体替换（解码进已有 map 会合并键并改动内存中的配置）
+	if idReq.Headers != nil {
+		req.Headers = nil
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, "请求体解析失败: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.ID = idReq.ID
+	// 开启脱敏时编辑弹窗拿到的是脱敏地址，未改动则沿用原地址，避免凭据被 *** 覆盖
+	if h.cfg.Get().MaskSecrets && req.URL == config.MaskURL(existing.URL) {
+		req.URL = existing.URL
+	}
+	// 请求头的值在接口中以 *** 展示、Basic 认证密码不回显，未改动的沿用原值
+	config.RestoreMaskedSecrets(&req.MonitorTask, existing)
+
+	globalVars := h.cfg.Get().Vars
+	name, normalizedURL, err := config.NormalizeAndValidateTaskInput(req.Name, req.URL, req.Type, globalVars, req.Vars)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// 扩展选项（如客户端证书）先于连通性校验检查，错误能直接指出配置问题
+	req.URL = normalizedURL
+	if err := config.ValidateTaskOptions(&req.MonitorTask, globalVars); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if !req.Force {
-		if err := probeURL(normalizedURL); err != nil {
+		// 模板地址已在规范化时校验可展开，这里对展开后的实际地址做连通性校验
+		target, _ := config.ExpandURL(normalizedURL, globalVars, req.Vars)
+		if err := probeTarget(target, req.Type); err != nil {
 			http.Error(w, "连通性校验失败: "+err.Error()+"（可选择强制保存）", http.StatusUnprocessableEntity)
 			return
 		}
 	}
 
-	task, oldURL, err := h.cfg.UpdateTask(req.ID, name, normalizedURL)
+	in := req.MonitorTask
+	in.Name, in.URL = name, normalizedURL
+	task, oldURL, err := h.cfg.UpdateTask(in)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -239,8 +460,141 @@ func (h *Handler) updateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	h.mon.SyncUpdatedTask(task, oldURL)
 	h.mon.TriggerNow()
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(task)
+	task = config.MaskTaskSecrets(task)
+	writeJSON(w, r, task)
+}
+
+// checkTaskHandler 立即检查单个任务并返回最新结果；过于频繁时返回 429 及需等待的秒数。
+func (h *Handler) checkTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	res, err := h.mon.CheckOne(id)
+	if err != nil {
+		var throttled *monitor.ThrottledError
+		if errors.As(err, &throttled) {
+			w.Header().Set("Retry-After", strconv.Itoa(throttled.WaitSeconds()))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, r, h.maskResults([]model.MonitorResult{res})[0])
+}
+
+// testTaskAlertHandler 按任务的告警路由向每个有效通道发送一条测试通知，返回各通道的投递结果；不写入事件日志。
+func (h *Handler) testTaskAlertHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	results, err := h.mon.TestTaskAlert(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	allOK := true
+	for _, c := range results {
+		allOK = allOK && c.OK
+	}
+	writeJSON(w, r, map[string]interface{}{"all_ok": allOK, "channels": results})
+}
+
+// checkBatchHandler 同步检查一组任务并返回全部结果，供发布后的 CI 门禁使用。
+// 请求体为 {"ids":[1,2]} 或 {"all":true}；all_ok 表示所有请求的任务均已检查且结果正常。
+func (h *Handler) checkBatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		IDs []int `json:"ids"`
+		All bool  `json:"all"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	if !req.All && len(req.IDs) == 0 {
+		http.Error(w, "ids 不能为空（检查全部任务请传 all=true）", http.StatusBadRequest)
+		return
+	}
+	ids := req.IDs
+	if req.All {
+		ids = nil
+	}
+	results, skipped := h.mon.CheckBatch(ids)
+	allOK := len(skipped) == 0
+	for _, res := range results {
+		if !res.IsSuccess {
+			allOK = false
+		}
+	}
+	if skipped == nil {
+		skipped = []monitor.BatchCheckError{}
+	}
+	writeJSON(w, r, map[string]any{
+		"all_ok":  allOK,
+		"results": h.maskResults(results),
+		"skipped": skipped,
+	})
+}
+
+// eventDetailHandler 返回单条事件详情，包括故障时刻保存的响应头快照（开启 capture_fail_headers 时）。
+func (h *Handler) eventDetailHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id == 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	e, err := h.repo.GetEvent(uint(id))
+	if err != nil {
+		http.Error(w, "未找到指定事件", http.StatusNotFound)
+		return
+	}
+	headers := []model.InspectHeader{}
+	if e.Headers != "" {
+		_ = json.Unmarshal([]byte(e.Headers), &headers)
+	}
+	writeJSON(w, r, map[string]any{
+		"id":           e.ID,
+		"task_name":    e.TaskName,
+		"tenant":       e.Tenant,
+		"event_time":   e.EventTime,
+		"type":         e.Type,
+		"message":      e.Message,
+		"is_resolved":  e.IsResolved,
+		"dedup_key":    e.DedupKey,
+		"headers":      headers,
+		"body_preview": e.BodyPreview,
+	})
+}
+
+// inspectTaskHandler 对任务执行一次性调试检查，返回状态行与脱敏后的响应头快照，不做任何持久化。
+func (h *Handler) inspectTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	res, err := h.mon.Inspect(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, r, res)
 }
 
 // deleteTaskHandler 处理删除任务的请求，并从监控状态中清理相关数据。
@@ -250,32 +604,321 @@ func (h *Handler) deleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		ID int `json:"id"`
+		ID        int  `json:"id"`
+		PurgeLogs bool `json:"purge_logs"` // 同时清除该任务的历史日志，默认保留以备审计
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
 		http.Error(w, "invalid id", http.StatusBadRequest)
 		return
 	}
-	delURL, err := h.cfg.DeleteTask(req.ID)
+	task, _ := h.cfg.GetTask(req.ID)
+	_, err := h.cfg.DeleteTask(req.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	h.mon.RemoveTaskState(req.ID, delURL) // 清理监控服务中的缓存状态
+	h.mon.RemoveTaskState(req.ID) // 清理监控服务中的缓存状态
+	if req.PurgeLogs {
+		perf := h.repo.DeletePerformanceByTask(req.ID)
+		var events int64
+		if task.Name != "" {
+			events = h.repo.DeleteEventsByTask(task.Name)
+		}
+		log.Printf("🧹 任务 [%s] 已删除，并清除 %d 条性能日志、%d 条事件日志", task.Name, perf, events)
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
+// cloneTaskHandler 复制 ?id= 指定任务的配置为新任务，返回新任务。
+func (h *Handler) cloneTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	task, err := h.cfg.CloneTask(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.mon.TriggerNow()
+
+	task = config.MaskTaskSecrets(task)
+	writeJSON(w, r, task)
+}
+
+// writeJSON 以 JSON 输出响应，所有 API 统一使用 snake_case 字段名；
+// 请求带 ?pretty=1 时按两空格缩进输出（与 config.json 落盘格式一致），便于手工调试。
+func writeJSON(w http.ResponseWriter, r *http.Request, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	if r.URL.Query().Get("pretty") == "1" {
+		enc.SetIndent("", "  ")
+	}
+	_ = enc.Encode(v)
+}
+
+// requireAdmin 校验请求携带的管理令牌（Authorization: Bearer <token> 或 X-Admin-Token），
+// 令牌来自环境变量 MONITOR_ADMIN_TOKEN；未配置时相关接口一律拒绝。校验失败时已写回错误响应。
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if os.Getenv("MONITOR_ADMIN_TOKEN") == "" {
+		http.Error(w, "未配置管理令牌 MONITOR_ADMIN_TOKEN，接口已禁用", http.StatusForbidden)
+		return false
+	}
+	if !adminTokenValid(r) {
+		http.Error(w, "管理令牌无效", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// adminTokenValid 判断请求是否携带与 MONITOR_ADMIN_TOKEN 一致的管理令牌，未配置令牌时一律为 false。
+func adminTokenValid(r *http.Request) bool {
+	expected := os.Getenv("MONITOR_ADMIN_TOKEN")
+	if expected == "" {
+		return false
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	if token == "" {
+		token = r.Header.Get("X-Admin-Token")
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// provisionHandler 接收外部编排系统提交的期望任务列表，声明式同步由 provision 管理的任务，
+// 返回新增/更新/删除的差异。任务按 external_key（缺省时按 URL）匹配，重复提交相同内容不产生变更。
+// 支持 dry_run 只预览差异。需要管理令牌。
+func (h *Handler) provisionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	var req struct {
+		Tasks  []model.MonitorTask `json:"tasks"`
+		DryRun bool                `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "请求体解析失败: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	// 缺少 tasks 字段时拒绝，避免误把空请求当作"删除全部"
+	if req.Tasks == nil {
+		http.Error(w, "缺少 tasks 字段；如需清空请显式提交空数组", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.cfg.Reconcile("provision", req.Tasks, req.DryRun, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !req.DryRun {
+		h.mon.ApplyReconcile(result)
+	}
+
+	result.Added = maskTaskSecrets(result.Added)
+	result.Updated = maskTaskSecrets(result.Updated)
+	result.Removed = maskTaskSecrets(result.Removed)
+	writeJSON(w, r, result)
+}
+
+// discoverySyncHandler 立即从 discovery_url 拉取并同步一次发现任务，返回差异；失败时不改动任务。
+func (h *Handler) discoverySyncHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	result, err := h.mon.SyncDiscovery(r.Context())
+	if errors.Is(err, monitor.ErrDiscoveryDisabled) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	result.Added = maskTaskSecrets(result.Added)
+	result.Updated = maskTaskSecrets(result.Updated)
+	result.Removed = maskTaskSecrets(result.Removed)
+	for i, sk := range result.Skipped {
+		result.Skipped[i].URL = config.MaskURL(sk.URL)
+		result.Skipped[i].Error = config.MaskURLIn(sk.Error, sk.URL)
+	}
+	writeJSON(w, r, result)
+}
+
+// alertPreviewHandler 用示例数据渲染告警模板并返回结果，template 为空时使用已保存的模板；
+// data 可覆盖示例数据中的字段。模板有误时返回 422 及错误信息，便于保存前发现问题。
+func (h *Handler) alertPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req := struct {
+		Template string                  `json:"template"`
+		Data     model.AlertTemplateData `json:"data"`
+	}{Data: config.SampleAlertData()}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "请求体解析失败: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	tpl := req.Template
+	if strings.TrimSpace(tpl) == "" {
+		tpl = h.cfg.Get().AlertTemplate
+	}
+	if strings.TrimSpace(tpl) == "" {
+		writeJSON(w, r, map[string]any{"output": req.Data.Message, "default": true})
+		return
+	}
+	out, err := config.RenderAlertTemplate(tpl, req.Data)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		writeJSON(w, r, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, r, map[string]any{"output": out, "default": false})
+}
+
+// incidentResolveHandler 接收外部工具的“故障已解决”信号，按任务 ID 或名称强制解除未恢复的告警并重置告警状态。
+// 接口幂等，可重复调用；resolved_by 记录到审计事件中。需要管理令牌。
+func (h *Handler) incidentResolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	var req struct {
+		ID         int    `json:"id"`
+		Name       string `json:"name"`
+		ResolvedBy string `json:"resolved_by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "请求体解析失败: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		task model.MonitorTask
+		ok   bool
+	)
+	switch {
+	case req.ID > 0:
+		task, ok = h.cfg.GetTask(req.ID)
+	case strings.TrimSpace(req.Name) != "":
+		task, ok = h.cfg.FindTaskByName(strings.TrimSpace(req.Name))
+	default:
+		http.Error(w, "需要提供任务 id 或 name", http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		http.Error(w, "未找到指定任务", http.StatusNotFound)
+		return
+	}
+
+	wasDown, resolved := h.mon.ResolveIncident(task, strings.TrimSpace(req.ResolvedBy))
+	writeJSON(w, r, map[string]any{
+		"task_id":         task.ID,
+		"task_name":       task.Name,
+		"was_down":        wasDown,
+		"resolved_events": resolved,
+	})
+}
+
+// archiveTaskHandler 处理任务归档：GET 列出已归档任务，POST 归档或恢复指定任务。
+// 归档任务保留配置与历史日志，但会从监控循环和看板中移除；恢复后立即重新纳入监控。
+func (h *Handler) archiveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, r, maskTaskSecrets(h.cfg.ArchivedTasks()))
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID       int  `json:"id"`
+		Archived bool `json:"archived"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	task, err := h.cfg.SetArchived(req.ID, req.Archived)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 归档与恢复都从干净的运行态开始，避免恢复后沿用旧的失败计数
+	h.mon.RemoveTaskState(task.ID)
+	if !task.Archived {
+		h.mon.TriggerNow()
+	}
+
+	task = config.MaskTaskSecrets(task)
+	writeJSON(w, r, task)
+}
+
+// pauseTaskHandler 暂停或恢复指定任务。暂停的任务保留配置、历史与最近一次结果，
+// 不再参与定时检查与告警；恢复后清零连续失败计数并立即检查。
+func (h *Handler) pauseTaskHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		ID     int  `json:"id"`
+		Paused bool `json:"paused"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	task, err := h.cfg.SetPaused(req.ID, req.Paused)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mon.SetTaskPaused(task.ID, task.Paused)
+	if !task.Paused {
+		h.mon.TriggerNow()
+	}
+
+	task = config.MaskTaskSecrets(task)
+	writeJSON(w, r, task)
+}
+
 // updateSettingsHandler 更新全局配置，保存后立即触发一轮检查应用新设置。
+// 监听地址无法热切换，修改后响应 restart_required 提示需重启进程。
 func (h *Handler) updateSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	var in model.Config
+	// 未提交 notifications_enabled 的调用方视为保持开启，避免误把所有通知静音；
+	// 未提交 retry_count 时保留当前值（0 表示不重试，不能作为“未填写”的判断依据）
+	in := model.Config{NotificationsEnabled: true, RetryCount: h.cfg.Get().RetryCount}
 	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	oldAddr := h.cfg.Get().ListenAddr
 	if err := h.cfg.UpdateSettings(in); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -283,6 +926,13 @@ func (h *Handler) updateSettingsHandler(w http.ResponseWriter, r *http.Request)
 	// 配置更新后立即按新配置跑一轮
 	h.mon.TriggerNow()
 
+	if newAddr := h.cfg.Get().ListenAddr; newAddr != oldAddr {
+		writeJSON(w, r, map[string]any{
+			"restart_required": true,
+			"message":          fmt.Sprintf("监听地址已改为 %s，需重启服务后生效", newAddr),
+		})
+		return
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -296,7 +946,7 @@ func (h *Handler) clearLogsHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-// chartDataHandler 返回指定任务的最近 50 条性能数据（时间点和响应时间），用于前端图表展示。
+// chartDataHandler 返回指定任务的最近 50 条性能数据（时间点、响应时间、状态码与是否失败），用于前端图表展示。
 func (h *Handler) chartDataHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(r.URL.Query().Get("id"))
 	if err != nil || id <= 0 {
@@ -307,14 +957,31 @@ func (h *Handler) chartDataHandler(w http.ResponseWriter, r *http.Request) {
 	out := struct {
 		Times  []string `json:"times"`
 		Values []int64  `json:"values"`
+		Codes  []int    `json:"status_codes"`
+		Failed []bool   `json:"failed"`
 	}{}
 	// 按时间正序返回，方便图表绘制
 	for i := len(logs) - 1; i >= 0; i-- {
 		out.Times = append(out.Times, logs[i].CheckTime)
 		out.Values = append(out.Values, logs[i].ResponseTime)
+		out.Codes = append(out.Codes, logs[i].StatusCode)
+		out.Failed = append(out.Failed, logs[i].Failed)
 	}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(out)
+	writeJSON(w, r, out)
+}
+
+// sparklineHandler 从内存环形缓冲区返回任务最近的检查结果，用于迷你趋势图，无需查询数据库。
+func (h *Handler) sparklineHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	points := h.mon.Sparkline(id)
+	if points == nil {
+		points = []model.SparkPoint{}
+	}
+	writeJSON(w, r, points)
 }
 
 // performanceLogsHandler 返回指定任务最近若干条性能日志，供独立日志面板展示。
@@ -344,75 +1011,184 @@ func (h *Handler) performanceLogsHandler(w http.ResponseWriter, r *http.Request)
 			"id":            l.ID,
 			"task_name":     l.TaskName,
 			"response_time": l.ResponseTime,
+			"status_code":   l.StatusCode,
+			"success":       !l.Failed,
 			"check_time":    l.CheckTime,
 			"recorded_at":   l.CreatedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(out)
+	writeJSON(w, r, out)
 }
 
-// sysStatsHandler 返回系统运行状态（协程数、内存使用、运行时长）。
+// sysStatsHandler 返回系统运行状态（协程数、内存使用、运行时长、邮件重发队列、批次重叠计数）。
 func (h *Handler) sysStatsHandler(w http.ResponseWriter, r *http.Request) {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 	up := time.Since(h.start)
+	snap := h.mon.MetricsSnapshot()
+	lastRun := ""
+	if !snap.LastRun.IsZero() {
+		lastRun = snap.LastRun.Format("2006-01-02 15:04:05")
+	}
 	stats := map[string]any{
-		"goroutines": runtime.NumGoroutine(),
-		"memory":     fmt.Sprintf("%.2f MB", float64(m.Alloc)/1024/1024),
-		"uptime":     fmt.Sprintf("%02d:%02d:%02d", int(up.Hours()), int(up.Minutes())%60, int(up.Seconds())%60),
+		"goroutines":   runtime.NumGoroutine(),
+		"memory":       fmt.Sprintf("%.2f MB", float64(m.Alloc)/1024/1024),
+		"uptime":       fmt.Sprintf("%02d:%02d:%02d", int(up.Hours()), int(up.Minutes())%60, int(up.Seconds())%60),
+		"mail_pending": snap.MailPending,
+		"mail_failed":  snap.MailFailed,
+
+		"batch_skipped":  snap.BatchSkipped,
+		"batch_overruns": snap.BatchOverruns,
+
+		"dns_hits":   snap.DNSHits,
+		"dns_misses": snap.DNSMisses,
+		"dns_stale":  snap.DNSStale,
+
+		"tasks_total":   len(snap.Results),
+		"tasks_up":      snap.Up,
+		"tasks_failing": snap.Failing,
+		"tasks_down":    len(snap.DownIDs),
+		"last_run":      lastRun,
 	}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(stats)
+	writeJSON(w, r, stats)
 }
 
-// exportCsvHandler 导出所有事件日志为 CSV 文件，包含 UTF-8 BOM 头以便 Excel 正确打开。
+// exportCsvHandler 导出所有事件日志为 CSV 文件，包含 UTF-8 BOM 头以便 Excel 正确打开；tenant 参数可限定项目，gzip=1 时压缩下载。
+// format=json 时改为导出 JSON 数组，便于下游系统按 dedup_key 导入。
 func (h *Handler) exportCsvHandler(w http.ResponseWriter, r *http.Request) {
 	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("kind")), "performance") {
 		h.exportPerformanceCsvHandler(w, r)
 		return
 	}
+	events := h.repo.QueryEvents(0, strings.TrimSpace(r.URL.Query().Get("tenant")))
+	if strings.EqualFold(strings.TrimSpace(r.URL.Query().Get("format")), "json") {
+		h.exportEventsJSON(w, r, events)
+		return
+	}
 
-	w.Header().Set("Content-Disposition", "attachment; filename=monitor_logs.csv")
-	w.Header().Set("Content-Type", "text/csv")
-	// 写入 UTF-8 BOM，使 Excel 识别中文
-	_, _ = w.Write([]byte("\xEF\xBB\xBF"))
-	writer := csv.NewWriter(w)
-	_ = writer.Write([]string{"ID", "时间", "任务名称", "类型", "消息内容", "是否修复"})
-	for _, l := range h.repo.QueryEvents(0) {
+	out := startCSVDownload(w, r, "monitor_logs.csv")
+	defer out.Close()
+	writer := csv.NewWriter(out)
+	_ = writer.Write([]string{"ID", "时间", "任务名称", "类型", "消息内容", "是否修复", "去重键"})
+	for _, l := range events {
 		_ = writer.Write([]string{
-			fmt.Sprintf("%d", l.ID), l.EventTime, l.TaskName, l.Type, l.Message, fmt.Sprintf("%v", l.IsResolved),
+			fmt.Sprintf("%d", l.ID), l.EventTime, l.TaskName, l.Type, l.Message, fmt.Sprintf("%v", l.IsResolved), l.DedupKey,
 		})
 	}
 	writer.Flush()
 }
 
+// exportEventsJSON 以 JSON 数组导出事件日志（字段与 /api/event 一致，不含响应头快照），gzip=1 时压缩下载。
+func (h *Handler) exportEventsJSON(w http.ResponseWriter, r *http.Request, events []model.EventLog) {
+	rows := make([]map[string]any, 0, len(events))
+	for _, e := range events {
+		rows = append(rows, map[string]any{
+			"id":          e.ID,
+			"task_name":   e.TaskName,
+			"tenant":      e.Tenant,
+			"event_time":  e.EventTime,
+			"type":        e.Type,
+			"message":     e.Message,
+			"is_resolved": e.IsResolved,
+			"dedup_key":   e.DedupKey,
+		})
+	}
+	if !wantGzip(r) {
+		w.Header().Set("Content-Disposition", "attachment; filename=monitor_logs.json")
+		writeJSON(w, r, rows)
+		return
+	}
+	w.Header().Set("Content-Disposition", "attachment; filename=monitor_logs.json.gz")
+	w.Header().Set("Content-Type", "application/gzip")
+	gz := gzip.NewWriter(w)
+	defer gz.Close()
+	_ = json.NewEncoder(gz).Encode(rows)
+}
+
+// exportPerformanceCsvHandler 以 CSV 流式导出性能日志，id 指定任务（省略为全部），
+// tenant 可限定项目，from/to（YYYY-MM-DD，按入库日期，含 to 当天）可选限定日期范围。
 func (h *Handler) exportPerformanceCsvHandler(w http.ResponseWriter, r *http.Request) {
-	taskID, _ := strconv.Atoi(r.URL.Query().Get("id"))
+	q := r.URL.Query()
+	taskID, _ := strconv.Atoi(q.Get("id"))
+	var from, to time.Time
+	if s := q.Get("from"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			http.Error(w, "from 日期格式应为 YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	if s := q.Get("to"); s != "" {
+		t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			http.Error(w, "to 日期格式应为 YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = t.AddDate(0, 0, 1)
+	}
 	filename := "performance_logs.csv"
 	if taskID > 0 {
 		filename = fmt.Sprintf("performance_logs_task_%d.csv", taskID)
 	}
 
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	w.Header().Set("Content-Type", "text/csv")
-	_, _ = w.Write([]byte("\xEF\xBB\xBF"))
-	writer := csv.NewWriter(w)
-	_ = writer.Write([]string{"ID", "任务ID", "任务名称", "检测时间", "响应时间(ms)", "入库时间"})
-	for _, l := range h.repo.QueryPerformance(taskID, 0) {
-		_ = writer.Write([]string{
+	out := startCSVDownload(w, r, filename)
+	defer out.Close()
+	writer := csv.NewWriter(out)
+	_ = writer.Write([]string{"ID", "任务ID", "任务名称", "检测时间", "响应时间(ms)", "状态码", "结果", "入库时间"})
+	rows := 0
+	err := h.repo.StreamPerformance(taskID, strings.TrimSpace(q.Get("tenant")), from, to, func(l model.PerformanceLog) error {
+		if err := writer.Write([]string{
 			fmt.Sprintf("%d", l.ID),
 			fmt.Sprintf("%d", l.TaskID),
 			l.TaskName,
 			l.CheckTime,
 			fmt.Sprintf("%d", l.ResponseTime),
+			fmt.Sprintf("%d", l.StatusCode),
+			perfOutcome(l.Failed),
 			l.CreatedAt.Format("2006-01-02 15:04:05"),
-		})
+		}); err != nil {
+			return err
+		}
+		// 定期把缓冲写给客户端，大量历史数据也不会堆积在内存里
+		if rows++; rows%500 == 0 {
+			writer.Flush()
+			out.Flush()
+		}
+		return writer.Error()
+	})
+	if err != nil {
+		log.Printf("⚠️ 性能日志导出中断: %v", err)
 	}
 	writer.Flush()
 }
 
+// perfOutcome 返回性能日志导出中的检查结果列。
+func perfOutcome(failed bool) string {
+	if failed {
+		return "失败"
+	}
+	return "成功"
+}
+
+// probeTarget 按任务类型校验连通性：tcp 任务尝试建立连接，ping 任务只确认主机名可解析（发送 ICMP 需要特权），
+// 其余按 probeURL 探测。
+func probeTarget(raw, taskType string) error {
+	if strings.EqualFold(taskType, model.TaskTypePing) {
+		_, err := net.LookupHost(raw)
+		return err
+	}
+	if strings.EqualFold(taskType, model.TaskTypeTCP) {
+		conn, err := net.DialTimeout("tcp", raw, 4*time.Second)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	return probeURL(raw)
+}
+
 // probeURL 尝试通过 HEAD 请求探测 URL 连通性，若 HEAD 不支持则回退到 GET 请求。
 // 只检查状态码是否 <500（非服务端错误），超时或网络错误视为失败。
 func probeURL(raw string) error {
@@ -458,48 +1234,33 @@ func (h *Handler) toggleStarHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	starred, err := h.cfg.ToggleStar(req.ID)
+	// 标星只是展示偏好，单独存库并立即反映到结果中，无需改写配置或重新检查
+	starred, err := h.mon.ToggleStar(req.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// 立即同步到监控结果缓存，避免前端快速点击时的闪烁/反复横跳
-	h.mon.UpdateStar(req.ID, starred)
-
-	// 异步刷新一次探测，确保后续数据一致
-	h.mon.TriggerNow()
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{
+	writeJSON(w, r, map[string]any{
 		"starred": starred,
 	})
 }
 
-// backupHandler 备份 config.json 与 monitor.db 到 backup 目录。
+// backupHandler 备份当前配置文件与 monitor.db 到 backup 目录；gzip=1 时以 .gz 压缩保存。
 func (h *Handler) backupHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	ts := time.Now().Format("20060102-150405")
-	backupDir := "backup"
-	os.MkdirAll(backupDir, 0755)
-
-	files := []string{"config.json", "monitor.db"}
-	copied := []string{}
-	for _, f := range files {
-		dst := filepath.Join(backupDir, fmt.Sprintf("%s-%s", ts, filepath.Base(f)))
-		if err := copyFile(f, dst); err != nil {
-			http.Error(w, "备份失败: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		copied = append(copied, dst)
+	copied, err := h.writeBackup(wantGzip(r))
+	if err != nil {
+		http.Error(w, "备份失败: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
+	pruneBackups(h.cfg.Get().BackupKeep)
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{
+	writeJSON(w, r, map[string]any{
 		"files": copied,
 	})
 }
@@ -528,6 +1289,12 @@ func (h *Handler) resetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 配置存储于数据库时，数据库为多个实例共用，不允许由单个实例一键清空
+	if h.cfg.Path() == "" {
+		http.Error(w, "配置存储于共享数据库，不支持一键重置", http.StatusConflict)
+		return
+	}
+
 	// 1) 关闭数据库连接
 	_ = h.repo.Close()
 
@@ -554,8 +1321,7 @@ func (h *Handler) resetHandler(w http.ResponseWriter, r *http.Request) {
 	h.ai.Reset(h.repo)
 	h.mon.TriggerNow()
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{
+	writeJSON(w, r, map[string]any{
 		"config":  cfg,
 		"message": "重置完成",
 	})
@@ -0,0 +1,91 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// promLabel 按 Prometheus 文本格式转义标签值。
+var promLabel = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsHandler 以 Prometheus 文本格式输出监控指标。所有指标取自同一份 MetricsSnapshot，
+// 保证一次抓取内的任务数、正常数与逐任务指标彼此一致。
+func (h *Handler) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	snap := h.mon.MetricsSnapshot()
+	down := make(map[int]bool, len(snap.DownIDs))
+	for _, id := range snap.DownIDs {
+		down[id] = true
+	}
+
+	var b strings.Builder
+	gauge := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+	counter := func(name, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	}
+
+	gauge("monitor_task_up", "最近一次检查是否成功 (1 成功, 0 失败)")
+	for _, res := range snap.Results {
+		fmt.Fprintf(&b, "monitor_task_up{task_id=\"%d\",task_name=\"%s\"} %d\n", res.ID, promLabel.Replace(res.TaskName), boolMetric(res.IsSuccess))
+	}
+	gauge("monitor_task_down", "任务是否已确认宕机 (达到告警阈值)")
+	for _, res := range snap.Results {
+		fmt.Fprintf(&b, "monitor_task_down{task_id=\"%d\",task_name=\"%s\"} %d\n", res.ID, promLabel.Replace(res.TaskName), boolMetric(down[res.ID]))
+	}
+	gauge("monitor_task_response_ms", "最近一次检查的响应耗时 (毫秒)")
+	for _, res := range snap.Results {
+		fmt.Fprintf(&b, "monitor_task_response_ms{task_id=\"%d\",task_name=\"%s\"} %d\n", res.ID, promLabel.Replace(res.TaskName), res.DurationInt)
+	}
+	gauge("monitor_task_consecutive_fails", "当前连续失败次数")
+	for _, res := range snap.Results {
+		fmt.Fprintf(&b, "monitor_task_consecutive_fails{task_id=\"%d\",task_name=\"%s\"} %d\n", res.ID, promLabel.Replace(res.TaskName), snap.ConsecutiveFails[res.ID])
+	}
+	// 只输出取得证书信息的任务，非 HTTPS 或握手失败的任务不给出误导性的 0 天
+	gauge("monitor_task_cert_expiry_days", "HTTPS 证书剩余有效天数")
+	for _, res := range snap.Results {
+		if res.HasCert {
+			fmt.Fprintf(&b, "monitor_task_cert_expiry_days{task_id=\"%d\",task_name=\"%s\"} %d\n", res.ID, promLabel.Replace(res.TaskName), res.CertDaysLeft)
+		}
+	}
+
+	gauge("monitor_tasks", "按状态统计的任务数")
+	fmt.Fprintf(&b, "monitor_tasks{state=\"total\"} %d\n", len(snap.Results))
+	fmt.Fprintf(&b, "monitor_tasks{state=\"up\"} %d\n", snap.Up)
+	fmt.Fprintf(&b, "monitor_tasks{state=\"failing\"} %d\n", snap.Failing)
+	fmt.Fprintf(&b, "monitor_tasks{state=\"down\"} %d\n", len(snap.DownIDs))
+
+	gauge("monitor_last_run_timestamp_seconds", "最近一次定时批次完成的 Unix 时间戳，尚未完成时为 0")
+	lastRun := int64(0)
+	if !snap.LastRun.IsZero() {
+		lastRun = snap.LastRun.Unix()
+	}
+	fmt.Fprintf(&b, "monitor_last_run_timestamp_seconds %d\n", lastRun)
+
+	counter("monitor_batch_skipped_total", "因上一批次未结束而跳过的批次数")
+	fmt.Fprintf(&b, "monitor_batch_skipped_total %d\n", snap.BatchSkipped)
+	counter("monitor_batch_overruns_total", "耗时超过监控间隔的批次数")
+	fmt.Fprintf(&b, "monitor_batch_overruns_total %d\n", snap.BatchOverruns)
+	gauge("monitor_mail_queue", "告警邮件重发队列中的邮件数")
+	fmt.Fprintf(&b, "monitor_mail_queue{state=\"pending\"} %d\n", snap.MailPending)
+	fmt.Fprintf(&b, "monitor_mail_queue{state=\"failed\"} %d\n", snap.MailFailed)
+	counter("monitor_dns_cache_total", "探测用 DNS 缓存的查询结果")
+	fmt.Fprintf(&b, "monitor_dns_cache_total{result=\"hit\"} %d\n", snap.DNSHits)
+	fmt.Fprintf(&b, "monitor_dns_cache_total{result=\"miss\"} %d\n", snap.DNSMisses)
+	fmt.Fprintf(&b, "monitor_dns_cache_total{result=\"stale\"} %d\n", snap.DNSStale)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func boolMetric(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"monitor/internal/model"
+)
+
+// 自签名证书保存在工作目录（与 monitor.db 同处），重启后沿用，浏览器只需信任一次。
+const (
+	selfSignedCertFile = "tls-selfsigned.crt"
+	selfSignedKeyFile  = "tls-selfsigned.key"
+	selfSignedValidity = 365 * 24 * time.Hour
+)
+
+// ServerTLSConfig 根据配置构造管理后台的 TLS 配置：提供了证书与私钥时直接加载，
+// 两者都未提供时使用（必要时生成）localhost 自签名证书，只提供其一视为配置错误。
+func ServerTLSConfig(cfg model.TLSConfig) (*tls.Config, error) {
+	certFile, keyFile := cfg.CertFile, cfg.KeyFile
+	switch {
+	case certFile != "" && keyFile != "":
+	case certFile == "" && keyFile == "":
+		if err := ensureSelfSignedCert(selfSignedCertFile, selfSignedKeyFile); err != nil {
+			return nil, fmt.Errorf("生成自签名证书失败: %w", err)
+		}
+		certFile, keyFile = selfSignedCertFile, selfSignedKeyFile
+	default:
+		return nil, errors.New("tls.cert_file 与 tls.key_file 需成对配置")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载证书失败: %w", err)
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+}
+
+// ensureSelfSignedCert 在证书文件不存在、无法解析或即将过期时重新生成覆盖 localhost、127.0.0.1、::1
+// 与本机主机名的自签名证书。
+func ensureSelfSignedCert(certFile, keyFile string) error {
+	if pair, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil && pair.Leaf != nil &&
+		time.Until(pair.Leaf.NotAfter) > 30*24*time.Hour {
+		return nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "localhost", Organization: []string{"Hakimi Monitor (self-signed)"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if host, err := os.Hostname(); err == nil && host != "" && host != "localhost" {
+		tpl.DNSNames = append(tpl.DNSNames, host)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+	log.Printf("🔏 已生成自签名证书 %s（有效期至 %s），仅适合本机或内网访问，浏览器首次访问需手动信任", certFile, tpl.NotAfter.Format("2006-01-02"))
+	return nil
+}